@@ -0,0 +1,43 @@
+package main
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"flag"
+	"fmt"
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
+	"log"
+	"strings"
+)
+
+// Creates an API key and prints the config entry holding its hash. Hand the
+// key to the client; only the hash goes into config.yaml.
+//
+//	go run ./cmd/apikey -name ingest -scopes write
+//	go run ./cmd/apikey -name reports -scopes read -indexes transfers,_default
+func main() {
+	name := flag.String("name", "", "name of the key, shown in errors")
+	scopes := flag.String("scopes", "read", "comma separated scopes: read, write, admin")
+	indexes := flag.String("indexes", "", "comma separated indexes the key may use, _default for the default index; empty for all")
+	flag.Parse()
+
+	if *name == "" {
+		log.Fatal("-name is required")
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		log.Fatalf("Error generating key: %v", err)
+	}
+	key := hex.EncodeToString(secret)
+
+	fmt.Printf("API key: %s\n\n", key)
+	fmt.Println("auth:")
+	fmt.Println("  keys:")
+	fmt.Printf("    - name: %s\n", *name)
+	fmt.Printf("      hash: %s\n", auth.Hash(key))
+	fmt.Printf("      scopes: [%s]\n", strings.Join(strings.Split(*scopes, ","), ", "))
+	if *indexes != "" {
+		fmt.Printf("      indexes: [%s]\n", strings.Join(strings.Split(*indexes, ","), ", "))
+	}
+}
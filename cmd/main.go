@@ -1,17 +1,32 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
 	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/health"
+	"github.com/ahmadrezamusthafa/search-engine/internal/metrics"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/grpc/pb"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/grpc/service"
 	"github.com/ahmadrezamusthafa/search-engine/internal/server/http/handler"
 	"github.com/ahmadrezamusthafa/search-engine/internal/server/http/router"
 	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
-	"github.com/ahmadrezamusthafa/search-engine/pkg/redisdb"
+	"github.com/prometheus/client_golang/prometheus"
+	"google.golang.org/grpc"
 	"log"
+	"net"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 )
 
+const defaultShutdownTimeout = 30 * time.Second
+
 func main() {
 	cfg, err := config.LoadConfig("config.yaml")
 	if err != nil {
@@ -19,25 +34,132 @@ func main() {
 	}
 
 	badgerDB := badgerdb.NewBadgerDB(cfg.Badger)
-	defer badgerDB.Close()
-	redis := redisdb.NewRedis(cfg.Redis)
-	defer redis.Close()
 
 	//Sample: Redis
+	//redis := redisdb.NewRedis(cfg.Redis)
 	//searchEngine, err := engine.NewSearchEngine(engine.PersistenceRedis, cfg, redis)
+	//indexes, err := engine.NewIndexRegistry(engine.PersistenceRedis, cfg, redis)
+	//snapshotter, err := engine.NewSnapshotter(engine.PersistenceRedis, redis)
+	//checker.Add("redis", health.Redis(redis))
+	//prometheus.MustRegister(metrics.NewCollector(searchEngine, indexes, nil, redis))
 
 	searchEngine, err := engine.NewSearchEngine(engine.PersistenceBadger, cfg, badgerDB)
 	if err != nil {
 		log.Fatalf("Error initiate search engine: %v", err)
 	}
 
-	h := handler.NewHandler(searchEngine)
-	r := router.NewRouter(h)
+	indexes, err := engine.NewIndexRegistry(engine.PersistenceBadger, cfg, badgerDB)
+	if err != nil {
+		log.Fatalf("Error initiate index registry: %v", err)
+	}
+
+	snapshotter, err := engine.NewSnapshotter(engine.PersistenceBadger, badgerDB)
+	if err != nil {
+		log.Fatalf("Error initiate snapshotter: %v", err)
+	}
+
+	keyring, err := auth.NewKeyring(cfg.Auth)
+	if err != nil {
+		log.Fatalf("Error loading API keys: %v", err)
+	}
+	if keyring == nil {
+		log.Println("No API keys configured, the HTTP and gRPC APIs are open to everyone")
+	}
+
+	prometheus.MustRegister(metrics.NewCollector(searchEngine, indexes, badgerDB, nil))
+
+	checker := health.NewChecker(cfg.Server.ReadinessTimeout)
+	checker.Add("badger", health.Badger(badgerDB))
+
+	h := handler.NewHandler(searchEngine, indexes, snapshotter, checker, cfg.Limits)
+	r := router.NewRouter(h, keyring, cfg.Limits)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	serverErr := make(chan error, 2)
+
+	var grpcServer *grpc.Server
+	if cfg.Server.GRPCPort != "" {
+		listener, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.Server.GRPCPort))
+		if err != nil {
+			log.Fatalf("Error listening for gRPC: %v", err)
+		}
+		grpcServer = grpc.NewServer(service.ServerOptions(keyring, cfg.Limits)...)
+		pb.RegisterSearchEngineServer(grpcServer, service.NewServer(searchEngine, cfg.Limits))
+
+		log.Printf("Starting gRPC server on %s...", listener.Addr())
+		go func() {
+			if err := grpcServer.Serve(listener); err != nil {
+				serverErr <- fmt.Errorf("gRPC server: %w", err)
+			}
+		}()
+	}
+
+	server := &http.Server{
+		Addr:    fmt.Sprintf(":%s", cfg.Server.Port),
+		Handler: r,
+	}
+	log.Printf("Starting server on %s...", server.Addr)
+	go func() {
+		if err := server.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	exitCode := 0
+	select {
+	case <-ctx.Done():
+		log.Println("Shutting down...")
+	case err := <-serverErr:
+		log.Printf("Error starting server: %v", err)
+		exitCode = 1
+	}
+	stop()
+
+	timeout := cfg.Server.ShutdownTimeout
+	if timeout <= 0 {
+		timeout = defaultShutdownTimeout
+	}
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	// Stop accepting requests and let the running ones finish, so no write
+	// is cut off halfway.
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("Error shutting down server: %v", err)
+	}
+	if grpcServer != nil {
+		stopGRPC(shutdownCtx, grpcServer)
+	}
+
+	// The engines flush their statistics before the storage closes.
+	if err := searchEngine.Close(); err != nil {
+		log.Printf("Error closing search engine: %v", err)
+	}
+	if err := indexes.Close(); err != nil {
+		log.Printf("Error closing indexes: %v", err)
+	}
+	if err := badgerDB.Close(); err != nil {
+		log.Printf("Error closing BadgerDB: %v", err)
+	}
+	log.Println("Server stopped")
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
 
-	addr := fmt.Sprintf(":%s", cfg.Server.Port)
-	log.Printf("Starting server on %s...", addr)
+// stopGRPC waits for running calls until ctx is done, then cancels them.
+func stopGRPC(ctx context.Context, grpcServer *grpc.Server) {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
 
-	if err := http.ListenAndServe(addr, r); err != nil {
-		log.Fatalf("Error starting server: %v", err)
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		log.Printf("Error shutting down gRPC server: %v", ctx.Err())
+		grpcServer.Stop()
 	}
 }
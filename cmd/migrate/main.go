@@ -0,0 +1,151 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/migration"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/redisdb"
+	"io"
+	"log"
+	"os"
+)
+
+// Moves documents between persistence backends through the portable JSON
+// lines format. Stop the server first when BadgerDB is involved, it locks its
+// directory.
+//
+//	go run ./cmd/migrate -from redis -to badger
+//	go run ./cmd/migrate -from redis -export docs.jsonl
+//	go run ./cmd/migrate -to badger -import docs.jsonl -index transfers
+func main() {
+	configPath := flag.String("config", "config.yaml", "path to the config file")
+	from := flag.String("from", "", "source persistence: badger or redis")
+	to := flag.String("to", "", "target persistence: badger or redis")
+	index := flag.String("index", "", "named index to read from and write to, default index when empty")
+	exportPath := flag.String("export", "", "write the source documents to this file instead of a target")
+	importPath := flag.String("import", "", "read documents from this file instead of a source")
+	flag.Parse()
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+	}
+
+	ctx := context.Background()
+	switch {
+	case *from != "" && *to != "" && *from != *to:
+		src, closeSrc := openEngine(cfg, *from, *index)
+		defer closeSrc()
+		dst, closeDst := openEngine(cfg, *to, *index)
+		defer closeDst()
+
+		reader, writer := io.Pipe()
+		go func() {
+			_, err := migration.Export(ctx, src, writer)
+			writer.CloseWithError(err)
+		}()
+
+		count, err := migration.Import(ctx, dst, reader)
+		if err != nil {
+			log.Fatalf("Error migrating documents: %v", err)
+		}
+		fmt.Printf("Migrated %d documents from %s to %s\n", count, *from, *to)
+	case *from != "" && *exportPath != "":
+		file, err := os.Create(*exportPath)
+		if err != nil {
+			log.Fatalf("Error creating export file: %v", err)
+		}
+		defer file.Close()
+
+		src, closeSrc := openEngine(cfg, *from, *index)
+		defer closeSrc()
+
+		count, err := migration.Export(ctx, src, file)
+		if err != nil {
+			log.Fatalf("Error exporting documents: %v", err)
+		}
+		fmt.Printf("Exported %d documents to %s\n", count, *exportPath)
+	case *to != "" && *importPath != "":
+		file, err := os.Open(*importPath)
+		if err != nil {
+			log.Fatalf("Error opening import file: %v", err)
+		}
+		defer file.Close()
+
+		dst, closeDst := openEngine(cfg, *to, *index)
+		defer closeDst()
+
+		count, err := migration.Import(ctx, dst, file)
+		if err != nil {
+			log.Fatalf("Error importing documents: %v", err)
+		}
+		fmt.Printf("Imported %d documents from %s\n", count, *importPath)
+	default:
+		flag.Usage()
+		os.Exit(2)
+	}
+}
+
+func openEngine(cfg *config.Config, persistence, index string) (engine.ISearchEngine, func()) {
+	var (
+		searchEngine engine.ISearchEngine
+		indexes      *engine.IndexRegistry
+		closeDB      func() error
+		err          error
+	)
+
+	switch persistence {
+	case engine.PersistenceBadger:
+		badgerDB := badgerdb.NewBadgerDB(cfg.Badger)
+		closeDB = badgerDB.Close
+		searchEngine, err = engine.NewSearchEngine(persistence, cfg, badgerDB)
+		if err == nil && index != "" {
+			indexes, err = engine.NewIndexRegistry(persistence, cfg, badgerDB)
+		}
+	case engine.PersistenceRedis:
+		redis := redisdb.NewRedis(cfg.Redis)
+		closeDB = redis.Close
+		searchEngine, err = engine.NewSearchEngine(persistence, cfg, redis)
+		if err == nil && index != "" {
+			indexes, err = engine.NewIndexRegistry(persistence, cfg, redis)
+		}
+	default:
+		log.Fatalf("Unsupported persistence %q: use redis or badger", persistence)
+	}
+	if err != nil {
+		log.Fatalf("Error initiate %s search engine: %v", persistence, err)
+	}
+
+	// Closing the engines first flushes their statistics.
+	defaultEngine := searchEngine
+	closeAll := func() {
+		if err := defaultEngine.Close(); err != nil {
+			log.Printf("Error closing %s search engine: %v", persistence, err)
+		}
+		if indexes != nil {
+			if err := indexes.Close(); err != nil {
+				log.Printf("Error closing %s indexes: %v", persistence, err)
+			}
+		}
+		if err := closeDB(); err != nil {
+			log.Printf("Error closing %s: %v", persistence, err)
+		}
+	}
+
+	if index == "" {
+		return searchEngine, closeAll
+	}
+
+	if _, ok := indexes.GetIndex(index); !ok {
+		_, err = indexes.PutIndex(index, indexes.DefaultSettings(index))
+		if err != nil {
+			log.Fatalf("Error preparing index %q: %v", index, err)
+		}
+	}
+	searchEngine, _ = indexes.GetIndex(index)
+	return searchEngine, closeAll
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/redisdb"
+	"log"
+	"os"
+)
+
+// Backs up or restores the search engine storage without the server running.
+// BadgerDB holds a directory lock, so stop the server before using the badger
+// persistence; use the /admin endpoints for a live instance.
+//
+//	go run ./cmd/snapshot -backup full.bak
+//	go run ./cmd/snapshot -backup incr.bak -since 1234
+//	go run ./cmd/snapshot -restore full.bak
+//	go run ./cmd/snapshot -restore incr.bak -incremental
+func main() {
+	configPath := flag.String("config", "config.yaml", "path to the config file")
+	persistence := flag.String("persistence", engine.PersistenceBadger, "storage to snapshot: badger or redis")
+	backupPath := flag.String("backup", "", "write a backup to this file")
+	restorePath := flag.String("restore", "", "restore the backup from this file")
+	since := flag.Uint64("since", 0, "only back up entries newer than this version (badger only)")
+	incremental := flag.Bool("incremental", false, "apply the restored backup over the previous restore instead of replacing everything")
+	flag.Parse()
+
+	if (*backupPath == "") == (*restorePath == "") {
+		log.Fatal("Exactly one of -backup or -restore is required")
+	}
+
+	err := run(*configPath, *persistence, *backupPath, *restorePath, *since, *incremental)
+	if err != nil {
+		log.Fatalf("Error %v", err)
+	}
+}
+
+// run returns its errors instead of exiting, so the storage is closed on
+// every path.
+func run(configPath, persistence, backupPath, restorePath string, since uint64, incremental bool) error {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	var snapshotter engine.ISnapshotter
+	switch persistence {
+	case engine.PersistenceBadger:
+		badgerDB := badgerdb.NewBadgerDB(cfg.Badger)
+		defer badgerDB.Close()
+		snapshotter, err = engine.NewSnapshotter(persistence, badgerDB)
+	default:
+		redis := redisdb.NewRedis(cfg.Redis)
+		defer redis.Close()
+		snapshotter, err = engine.NewSnapshotter(persistence, redis)
+	}
+	if err != nil {
+		return fmt.Errorf("initiate snapshotter: %w", err)
+	}
+
+	if backupPath != "" {
+		file, err := os.Create(backupPath)
+		if err != nil {
+			return fmt.Errorf("creating backup file: %w", err)
+		}
+		defer file.Close()
+
+		version, err := snapshotter.Backup(file, since)
+		if err != nil {
+			return fmt.Errorf("writing backup: %w", err)
+		}
+		fmt.Printf("Backup written to %s, next incremental since: %d\n", backupPath, version)
+		return nil
+	}
+
+	file, err := os.Open(restorePath)
+	if err != nil {
+		return fmt.Errorf("opening backup file: %w", err)
+	}
+	defer file.Close()
+
+	if err := snapshotter.Restore(file, incremental); err != nil {
+		return fmt.Errorf("restoring backup: %w", err)
+	}
+	fmt.Printf("Restored %s\n", restorePath)
+	return nil
+}
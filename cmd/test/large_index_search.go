@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"github.com/ahmadrezamusthafa/search-engine/config"
 	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
@@ -32,7 +33,9 @@ func generateLargeIndex(searchEngine engine.ISearchEngine, numDocs int) {
 	for i := 0; i < numDocs; i++ {
 		docID := fmt.Sprintf("doc-%d", i+1)
 		tokens := generateDocument()
-		searchEngine.StoreDocument(docID, tokens)
+		if err := searchEngine.StoreDocument(context.Background(), docID, tokens); err != nil {
+			log.Fatalf("Error indexing %s: %v", docID, err)
+		}
 
 		if (i+1)%1000 == 0 {
 			fmt.Printf("Indexed %d documents\n", i+1)
@@ -60,10 +63,13 @@ func main() {
 
 	queries := []string{"abc"}
 	start = time.Now()
-	results := searchEngine.Search(queries...)
-	fmt.Printf("Search results for %v: %d documents found in %v\n", queries, len(results), time.Since(start))
+	response, err := searchEngine.Search(context.Background(), queries...)
+	if err != nil {
+		log.Fatalf("Error searching: %v", err)
+	}
+	fmt.Printf("Search results for %v: %d documents found in %v\n", queries, len(response.Hits), time.Since(start))
 
-	for _, result := range results {
+	for _, result := range response.Hits {
 		fmt.Println(result)
 	}
 }
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"github.com/ahmadrezamusthafa/search-engine/config"
 	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
 	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
@@ -24,17 +25,18 @@ func BenchmarkBadgerSearchEngine(b *testing.B) {
 	defer db.Close()
 
 	searchEngine := engine.NewBadgerSearchEngine(cfg.BM25, db)
+	ctx := context.Background()
 
-	searchEngine.StoreDocument("doc1", []string{"abc", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc2", []string{"bvbv", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc3", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc3a", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc3b", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc3c", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc4", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri", "abc"})
+	searchEngine.StoreDocument(ctx, "doc1", []string{"abc", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc2", []string{"bvbv", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc3", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc3a", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc3b", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc3c", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc4", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri", "abc"})
 
 	for n := 0; n < b.N; n++ {
-		searchEngine.Search("abc")
+		searchEngine.Search(ctx, "abc")
 	}
 }
 
@@ -52,16 +54,17 @@ func BenchmarkRedisSearchEngine(b *testing.B) {
 	defer redis.Close()
 
 	searchEngine := engine.NewRedisSearchEngine(cfg.BM25, redis)
+	ctx := context.Background()
 
-	searchEngine.StoreDocument("doc1", []string{"abc", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc2", []string{"bvbv", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc3", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc3a", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc3b", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc3c", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
-	searchEngine.StoreDocument("doc4", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri", "abc"})
+	searchEngine.StoreDocument(ctx, "doc1", []string{"abc", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc2", []string{"bvbv", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc3", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc3a", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc3b", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc3c", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri"})
+	searchEngine.StoreDocument(ctx, "doc4", []string{"hgh", "nasbdm", "aksjdhaks", "iuyiuweyri", "abc"})
 
 	for n := 0; n < b.N; n++ {
-		searchEngine.Search("abc")
+		searchEngine.Search(ctx, "abc")
 	}
 }
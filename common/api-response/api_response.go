@@ -9,6 +9,8 @@ type APIResponse struct {
 	Status  string      `json:"status"`
 	Message string      `json:"message,omitempty"`
 	Data    interface{} `json:"data,omitempty"`
+	// TimedOut marks search hits cut short by the search timeout.
+	TimedOut bool `json:"timed_out,omitempty"`
 }
 
 func RespondJSON(w http.ResponseWriter, statusCode int, response APIResponse) {
@@ -13,10 +13,30 @@ type Config struct {
 	Badger BadgerConfig `yaml:"badger"`
 	Redis  RedisConfig  `yaml:"redis"`
 	BM25   BM25Config   `yaml:"bm25"`
+	// Similarity selects the scoring model of the default index.
+	Similarity SimilarityConfig `yaml:"similarity"`
+	// BM25F holds the field weights of the default index.
+	BM25F BM25FConfig `yaml:"bm25f"`
+	// Indexes holds per-index overrides for named indexes, which are created
+	// on startup when missing. The options an entry sets are applied over the
+	// stored settings of an existing index on startup.
+	Indexes map[string]IndexConfig `yaml:"indexes"`
+	// Auth holds the API keys of the HTTP and gRPC APIs.
+	Auth AuthConfig `yaml:"auth"`
+	// Limits protects the HTTP and gRPC APIs from oversized and excessive
+	// requests.
+	Limits LimitsConfig `yaml:"limits"`
 }
 
 type ServerConfig struct {
 	Port string `yaml:"port"`
+	// GRPCPort serves the gRPC API next to the HTTP one; empty disables it.
+	GRPCPort string `yaml:"grpc_port"`
+	// ShutdownTimeout bounds how long running requests may finish after
+	// SIGINT or SIGTERM, default 30s.
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	// ReadinessTimeout bounds the dependency checks of /readyz, default 2s.
+	ReadinessTimeout time.Duration `yaml:"readiness_timeout"`
 }
 
 type BadgerConfig struct {
@@ -38,8 +58,89 @@ type RedisConfig struct {
 }
 
 type BM25Config struct {
-	K1 float64 `yaml:"k1"`
-	B  float64 `yaml:"b"`
+	K1 float64 `yaml:"k1" json:"k1"`
+	B  float64 `yaml:"b" json:"b"`
+}
+
+type SimilarityConfig struct {
+	// Model is one of bm25 (default), bm25plus, bm25l, tfidf or boolean.
+	Model string `yaml:"model" json:"model,omitempty"`
+	// Delta is the lower bound added to the term frequency weight by bm25plus
+	// and bm25l.
+	Delta float64 `yaml:"delta" json:"delta,omitempty"`
+}
+
+// BM25FConfig weights matches per field. When any field is weighted, or a
+// query boosts fields, documents are scored with BM25F instead of the
+// configured similarity.
+type BM25FConfig struct {
+	Fields map[string]FieldConfig `yaml:"fields" json:"fields,omitempty"`
+}
+
+type FieldConfig struct {
+	// Weight multiplies the term frequency of the field, default 1.
+	Weight float64 `yaml:"weight" json:"weight,omitempty"`
+	// B is the length normalization of the field, default bm25.b.
+	B float64 `yaml:"b" json:"b,omitempty"`
+}
+
+type IndexConfig struct {
+	BM25       BM25Config       `yaml:"bm25"`
+	Similarity SimilarityConfig `yaml:"similarity"`
+	BM25F      BM25FConfig      `yaml:"bm25f"`
+}
+
+// AuthConfig lists the accepted API keys. Without keys the API is open.
+type AuthConfig struct {
+	// Header carries the API key, default X-API-Key.
+	Header string         `yaml:"header"`
+	Keys   []APIKeyConfig `yaml:"keys"`
+}
+
+type APIKeyConfig struct {
+	Name string `yaml:"name"`
+	// Hash is the hex SHA-256 of the key; go run ./cmd/apikey creates both.
+	Hash string `yaml:"hash"`
+	// Scopes are read, write and admin; admin implies the others.
+	Scopes []string `yaml:"scopes"`
+	// Indexes restricts the key to these named indexes, with _default for
+	// the default index. Empty allows every index.
+	Indexes []string `yaml:"indexes"`
+}
+
+const (
+	DefaultMaxBodyBytes  = 1 << 20
+	DefaultMaxQueryTerms = 64
+)
+
+type LimitsConfig struct {
+	// MaxBodyBytes bounds JSON request bodies, default 1 MiB. Backup,
+	// restore and import streams are not bounded.
+	MaxBodyBytes int64 `yaml:"max_body_bytes"`
+	// MaxQueryTerms bounds the terms of a search, default 64.
+	MaxQueryTerms int `yaml:"max_query_terms"`
+	// PerKey limits the requests of every API key, PerIP those of every
+	// client address. A zero rate disables the limit.
+	PerKey RateLimitConfig `yaml:"per_key"`
+	PerIP  RateLimitConfig `yaml:"per_ip"`
+}
+
+// WithDefaults fills the unset size limits with their defaults.
+func (c LimitsConfig) WithDefaults() LimitsConfig {
+	if c.MaxBodyBytes <= 0 {
+		c.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if c.MaxQueryTerms <= 0 {
+		c.MaxQueryTerms = DefaultMaxQueryTerms
+	}
+	return c
+}
+
+// RateLimitConfig is a token bucket refilled with Rate tokens per second and
+// holding at most Burst, default the rate rounded up.
+type RateLimitConfig struct {
+	Rate  float64 `yaml:"rate"`
+	Burst int     `yaml:"burst"`
 }
 
 func LoadConfig(filename string) (*Config, error) {
@@ -0,0 +1,119 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"slices"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+)
+
+type Scope string
+
+const (
+	ScopeRead  Scope = "read"
+	ScopeWrite Scope = "write"
+	// ScopeAdmin allows everything, including the index settings and the
+	// /admin endpoints.
+	ScopeAdmin Scope = "admin"
+)
+
+const (
+	DefaultHeader = "X-API-Key"
+	// DefaultIndex names the default index in index allow-lists.
+	DefaultIndex = "_default"
+	// AllIndexes is requested by operations on the whole database, which
+	// only keys without an allow-list may run.
+	AllIndexes = "*"
+)
+
+// Key is an accepted API key.
+type Key struct {
+	Name    string
+	Scopes  []Scope
+	Indexes []string
+	hash    []byte
+}
+
+// HasScope reports whether the key grants the scope.
+func (k Key) HasScope(scope Scope) bool {
+	return slices.Contains(k.Scopes, scope) || slices.Contains(k.Scopes, ScopeAdmin)
+}
+
+// CanAccess reports whether the key may use the index.
+func (k Key) CanAccess(index string) bool {
+	return len(k.Indexes) == 0 || slices.Contains(k.Indexes, index)
+}
+
+// Keyring holds the API keys of the config. Only their hashes are kept.
+type Keyring struct {
+	header string
+	keys   []Key
+}
+
+// NewKeyring reads the API keys of the config. It returns nil when there
+// are none, which leaves the API open.
+func NewKeyring(cfg config.AuthConfig) (*Keyring, error) {
+	if len(cfg.Keys) == 0 {
+		return nil, nil
+	}
+
+	keyring := &Keyring{header: cfg.Header}
+	if keyring.header == "" {
+		keyring.header = DefaultHeader
+	}
+	names := make(map[string]bool)
+	for _, keyConfig := range cfg.Keys {
+		if keyConfig.Name == "" || names[keyConfig.Name] {
+			return nil, fmt.Errorf("api key %q: needs a unique name", keyConfig.Name)
+		}
+		names[keyConfig.Name] = true
+
+		hash, err := hex.DecodeString(keyConfig.Hash)
+		if err != nil || len(hash) != sha256.Size {
+			return nil, fmt.Errorf("api key %q: hash must be a hex SHA-256", keyConfig.Name)
+		}
+		if len(keyConfig.Scopes) == 0 {
+			return nil, fmt.Errorf("api key %q: needs at least one scope", keyConfig.Name)
+		}
+
+		key := Key{Name: keyConfig.Name, Indexes: keyConfig.Indexes, hash: hash}
+		for _, scope := range keyConfig.Scopes {
+			switch Scope(scope) {
+			case ScopeRead, ScopeWrite, ScopeAdmin:
+				key.Scopes = append(key.Scopes, Scope(scope))
+			default:
+				return nil, fmt.Errorf("api key %q: unknown scope %q, use read, write or admin", keyConfig.Name, scope)
+			}
+		}
+		keyring.keys = append(keyring.keys, key)
+	}
+	return keyring, nil
+}
+
+// Header is the request header carrying the API key.
+func (k *Keyring) Header() string {
+	return k.header
+}
+
+// Authenticate returns the key matching the presented secret.
+func (k *Keyring) Authenticate(secret string) (Key, bool) {
+	if secret == "" {
+		return Key{}, false
+	}
+	hash := sha256.Sum256([]byte(secret))
+	for _, key := range k.keys {
+		if subtle.ConstantTimeCompare(hash[:], key.hash) == 1 {
+			return key, true
+		}
+	}
+	return Key{}, false
+}
+
+// Hash returns the hex SHA-256 of a key as the config expects it.
+func Hash(secret string) string {
+	hash := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(hash[:])
+}
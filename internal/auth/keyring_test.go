@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+)
+
+func TestKeyring(t *testing.T) {
+	keyring, err := NewKeyring(config.AuthConfig{Keys: []config.APIKeyConfig{
+		{Name: "reader", Hash: Hash("secret-reader"), Scopes: []string{"read"}, Indexes: []string{"transfers"}},
+		{Name: "admin", Hash: Hash("secret-admin"), Scopes: []string{"admin"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if keyring.Header() != DefaultHeader {
+		t.Errorf("Header() = %q, want %q", keyring.Header(), DefaultHeader)
+	}
+
+	tests := []struct {
+		secret  string
+		scope   Scope
+		index   string
+		wantKey bool
+		allowed bool
+	}{
+		{secret: "secret-reader", scope: ScopeRead, index: "transfers", wantKey: true, allowed: true},
+		{secret: "secret-reader", scope: ScopeWrite, index: "transfers", wantKey: true, allowed: false},
+		{secret: "secret-reader", scope: ScopeRead, index: DefaultIndex, wantKey: true, allowed: false},
+		{secret: "secret-reader", scope: ScopeAdmin, index: AllIndexes, wantKey: true, allowed: false},
+		{secret: "secret-admin", scope: ScopeWrite, index: "transfers", wantKey: true, allowed: true},
+		{secret: "secret-admin", scope: ScopeAdmin, index: AllIndexes, wantKey: true, allowed: true},
+		{secret: "wrong", wantKey: false},
+		{secret: "", wantKey: false},
+	}
+	for _, tt := range tests {
+		key, ok := keyring.Authenticate(tt.secret)
+		if ok != tt.wantKey {
+			t.Errorf("Authenticate(%q) = %v, want %v", tt.secret, ok, tt.wantKey)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if allowed := key.HasScope(tt.scope) && key.CanAccess(tt.index); allowed != tt.allowed {
+			t.Errorf("key %q on %s of %q allowed = %v, want %v", key.Name, tt.scope, tt.index, allowed, tt.allowed)
+		}
+	}
+}
+
+func TestNewKeyringRejectsInvalidKeys(t *testing.T) {
+	for name, keyConfig := range map[string]config.APIKeyConfig{
+		"plain hash":    {Name: "k", Hash: "secret", Scopes: []string{"read"}},
+		"no scopes":     {Name: "k", Hash: Hash("secret")},
+		"unknown scope": {Name: "k", Hash: Hash("secret"), Scopes: []string{"delete"}},
+		"no name":       {Hash: Hash("secret"), Scopes: []string{"read"}},
+	} {
+		if _, err := NewKeyring(config.AuthConfig{Keys: []config.APIKeyConfig{keyConfig}}); err == nil {
+			t.Errorf("%s: NewKeyring() succeeded", name)
+		}
+	}
+
+	if keyring, err := NewKeyring(config.AuthConfig{}); keyring != nil || err != nil {
+		t.Errorf("NewKeyring() without keys = %v, %v, want nil, nil", keyring, err)
+	}
+}
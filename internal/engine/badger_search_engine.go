@@ -1,189 +1,746 @@
 package engine
 
 import (
-	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"context"
+	"encoding/json"
 	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/metrics"
+	"github.com/ahmadrezamusthafa/search-engine/internal/query"
 	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
 	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/hnsw"
+	"github.com/dgraph-io/badger/v4"
 	"log"
-	"math"
-	"sort"
+	"strings"
 	"sync"
 	"time"
 )
 
 type BadgerSearchEngine struct {
-	mu       sync.RWMutex
-	badgerDB *badgerdb.BadgerDB
-	tokenLen int
-	docCount int
-	k1       float64
-	b        float64
+	mu            sync.RWMutex
+	badgerDB      *badgerdb.BadgerDB
+	prefix        string
+	tokenLen      int
+	docCount      int
+	fieldTokenLen map[string]int
+	settings      IndexSettings
+	sim           Similarity
+	vectors       *hnsw.Index
+	metrics       metrics.Index
+	stopJanitor   func()
+	closed        bool
 }
 
 const BadgerTTL = 2 * time.Hour
 
 func NewBadgerSearchEngine(config config.BM25Config, badgerDB *badgerdb.BadgerDB) ISearchEngine {
-	tokenLen, docCount := repopulateDataFromBadger(badgerDB)
-	return &BadgerSearchEngine{
-		tokenLen: tokenLen,
-		docCount: docCount,
-		badgerDB: badgerDB,
-		k1:       config.K1,
-		b:        config.B,
+	return newBadgerSearchEngine("", IndexSettings{BM25: config}, badgerDB)
+}
+
+// newBadgerSearchEngine creates an engine whose keys all start with prefix,
+// giving it its own postings and statistics inside the shared database.
+func newBadgerSearchEngine(prefix string, settings IndexSettings, badgerDB *badgerdb.BadgerDB) *BadgerSearchEngine {
+	tokenLen, docCount, fieldTokenLen := repopulateDataFromBadger(prefix, badgerDB)
+	se := &BadgerSearchEngine{
+		tokenLen:      tokenLen,
+		docCount:      docCount,
+		fieldTokenLen: fieldTokenLen,
+		badgerDB:      badgerDB,
+		prefix:        prefix,
+		settings:      settings,
+		sim:           settings.newSimilarity(),
+		vectors:       loadVectorsFromBadger(prefix, badgerDB),
+		metrics:       metrics.ForIndex(indexName(prefix)),
 	}
+	se.stopJanitor = startPeriodic(ExpiryJanitorInterval, se.reconcileExpired)
+	return se
 }
 
-func repopulateDataFromBadger(badgerDB *badgerdb.BadgerDB) (int, int) {
-	tokenLen, err := badgerDB.GetInt("tokenLen")
+func repopulateDataFromBadger(prefix string, badgerDB *badgerdb.BadgerDB) (int, int, map[string]int) {
+	fieldTokenLen := make(map[string]int)
+	tokenLen, err := badgerDB.GetInt(prefix + "tokenLen")
 	if err != nil {
-		return 0, 0
+		return 0, 0, fieldTokenLen
 	}
-	docCount, err := badgerDB.GetInt("docCount")
+	docCount, err := badgerDB.GetInt(prefix + "docCount")
+	if err != nil {
+		return 0, 0, fieldTokenLen
+	}
+	err = badgerDB.GetObject(prefix+"fieldTokenLen", &fieldTokenLen)
+	if err != nil {
+		log.Println(err)
+	}
+	return tokenLen, docCount, fieldTokenLen
+}
+
+// loadVectorsFromBadger rebuilds the kNN graph from the stored vectors.
+func loadVectorsFromBadger(prefix string, badgerDB *badgerdb.BadgerDB) *hnsw.Index {
+	vectors := hnsw.New(0, 0)
+	vectorPrefix := prefix + "vector:"
+	err := badgerDB.IteratePrefix(vectorPrefix, func(key string, value []byte) bool {
+		var vector []float32
+		err := json.Unmarshal(value, &vector)
+		if err == nil {
+			err = vectors.Add(strings.TrimPrefix(key, vectorPrefix), vector)
+		}
+		if err != nil {
+			log.Println(err)
+		}
+		return true
+	})
 	if err != nil {
-		return 0, 0
+		log.Println(err)
+	}
+	return vectors
+}
+
+func (se *BadgerSearchEngine) key(name string) string {
+	return se.prefix + name
+}
+
+func (se *BadgerSearchEngine) applySettings(settings IndexSettings) {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	se.settings = settings
+	se.sim = settings.newSimilarity()
+}
+
+// StoreDocument stores a document. The context is checked once the write
+// lock is held; a started write is not interrupted.
+func (se *BadgerSearchEngine) StoreDocument(ctx context.Context, docID string, tokens []string, contents ...structs.Content) error {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if se.closed {
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return storageError(err)
 	}
-	return tokenLen, docCount
+	return se.storeDocument(docID, tokens, nil, time.Now().Add(BadgerTTL), contents...)
 }
 
-func (se *BadgerSearchEngine) StoreDocument(docID string, tokens []string, contents ...structs.Content) {
+func (se *BadgerSearchEngine) StoreVectorDocument(ctx context.Context, docID string, tokens []string, vector []float32, contents ...structs.Content) error {
 	se.mu.Lock()
 	defer se.mu.Unlock()
 
+	if se.closed {
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return storageError(err)
+	}
+	if err := checkVector(se.vectors, vector); err != nil {
+		return invalidError(err)
+	}
+	return se.storeDocument(docID, tokens, vector, time.Now().Add(BadgerTTL), contents...)
+}
+
+func (se *BadgerSearchEngine) ImportDocument(ctx context.Context, doc structs.ExportedDocument) error {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if se.closed {
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return storageError(err)
+	}
+	expiresAt, err := importExpiry(doc.ExpiresAt, BadgerTTL)
+	if err != nil {
+		return err
+	}
+	if doc.Vector != nil {
+		if err = checkVector(se.vectors, doc.Vector); err != nil {
+			return invalidError(err)
+		}
+	}
+	return se.storeDocument(doc.ID, doc.Tokens, doc.Vector, expiresAt, doc.Contents()...)
+}
+
+func (se *BadgerSearchEngine) DeleteDocument(ctx context.Context, docID string) error {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if se.closed {
+		return ErrClosed
+	}
+	if err := ctx.Err(); err != nil {
+		return storageError(err)
+	}
+	tracker, err := se.getTracker(ctx, docID)
+	if err != nil {
+		return err
+	}
+	if !tracker.isTracked() || tracker.isExpired(time.Now().Unix()) {
+		return ErrDocumentNotFound
+	}
+	return se.removeDocument(docID)
+}
+
+// collectionCounters are the collection statistics that a write changes. A
+// write works on a copy, which replaces the counters of the engine once the
+// write has committed.
+type collectionCounters struct {
+	tokenLen      int
+	docCount      int
+	fieldTokenLen map[string]int
+}
+
+func (se *BadgerSearchEngine) counters() collectionCounters {
+	fieldTokenLen := make(map[string]int, len(se.fieldTokenLen))
+	for field, length := range se.fieldTokenLen {
+		fieldTokenLen[field] = length
+	}
+	return collectionCounters{tokenLen: se.tokenLen, docCount: se.docCount, fieldTokenLen: fieldTokenLen}
+}
+
+func (se *BadgerSearchEngine) setCounters(counters collectionCounters) {
+	se.tokenLen, se.docCount, se.fieldTokenLen = counters.tokenLen, counters.docCount, counters.fieldTokenLen
+}
+
+// writeCounters stores counters inside txn.
+func (se *BadgerSearchEngine) writeCounters(txn *badger.Txn, counters collectionCounters) error {
+	err := badgerdb.SetInt(txn, se.key("tokenLen"), counters.tokenLen, BadgerTTL)
+	if err != nil {
+		return err
+	}
+	err = badgerdb.SetInt(txn, se.key("docCount"), counters.docCount, BadgerTTL)
+	if err != nil {
+		return err
+	}
+	return badgerdb.SetObject(txn, se.key("fieldTokenLen"), counters.fieldTokenLen, BadgerTTL)
+}
+
+// storeDocument replaces docID with a version that expires at expiresAt in a
+// single transaction, so a failed write leaves the previous version in place.
+// The caller must hold the write lock.
+func (se *BadgerSearchEngine) storeDocument(docID string, tokens []string, vector []float32, expiresAt time.Time, contents ...structs.Content) error {
+	ttl := time.Until(expiresAt)
+
 	tokenFrequency := make(map[string]int)
 	for _, token := range tokens {
 		tokenFrequency[token]++
 	}
+	fieldFrequency, fieldLengths := fieldTokenFrequency(tokenFrequency, contents...)
+	tracker := docTracker{
+		ExpiresAt:    expiresAt.Unix(),
+		Length:       len(tokens),
+		Tokens:       tokenFrequency,
+		FieldLengths: fieldLengths,
+	}
 
-	se.tokenLen += len(tokens)
-	se.docCount++
-
-	for token, freq := range tokenFrequency {
-		termDocCount, err := se.badgerDB.GetInt("termDocCount:" + token)
+	counters := se.counters()
+	err := se.badgerDB.DB.Update(func(txn *badger.Txn) error {
+		err := se.removeTracked(txn, docID, &counters)
 		if err != nil {
-			log.Println(err)
+			return err
 		}
-		termDocCount++
-		err = se.badgerDB.SetInt("termDocCount:"+token, termDocCount, BadgerTTL)
-		if err != nil {
-			log.Println(err)
+
+		counters.tokenLen += len(tokens)
+		counters.docCount++
+		for field, length := range fieldLengths {
+			counters.fieldTokenLen[field] += length
 		}
-		var currentIndexData map[string]int
-		err = se.badgerDB.GetObject("index:"+token, &currentIndexData)
+
+		for token, freq := range tokenFrequency {
+			termDocCount, err := badgerdb.GetInt(txn, se.key("termDocCount:"+token))
+			if err != nil {
+				return err
+			}
+			err = badgerdb.SetInt(txn, se.key("termDocCount:"+token), termDocCount+1, BadgerTTL)
+			if err != nil {
+				return err
+			}
+
+			currentIndexData := make(map[string]int)
+			err = badgerdb.GetObject(txn, se.key("index:"+token), &currentIndexData)
+			if err != nil {
+				return err
+			}
+			currentIndexData[docID] = freq
+			err = badgerdb.SetObject(txn, se.key("index:"+token), currentIndexData, BadgerTTL)
+			if err != nil {
+				return err
+			}
+
+			fieldFreqs, ok := fieldFrequency[token]
+			if !ok {
+				continue
+			}
+			currentFieldIndexData := make(map[string]map[string]int)
+			err = badgerdb.GetObject(txn, se.key("fieldIndex:"+token), &currentFieldIndexData)
+			if err != nil {
+				return err
+			}
+			currentFieldIndexData[docID] = fieldFreqs
+			err = badgerdb.SetObject(txn, se.key("fieldIndex:"+token), currentFieldIndexData, BadgerTTL)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = se.writeCounters(txn, counters); err != nil {
+			return err
+		}
+		err = badgerdb.SetInt(txn, se.key("docTokensLen:"+docID), len(tokens), ttl)
 		if err != nil {
-			log.Println(err)
+			return err
+		}
+		if len(fieldLengths) > 0 {
+			err = badgerdb.SetObject(txn, se.key("docFieldsLen:"+docID), fieldLengths, ttl)
+			if err != nil {
+				return err
+			}
 		}
-		if currentIndexData == nil {
-			currentIndexData = make(map[string]int)
+		if len(contents) > 0 {
+			err = badgerdb.SetObject(txn, se.key("data:"+docID), storedContent(contents[0]), ttl)
+			if err != nil {
+				return err
+			}
 		}
-		currentIndexData[docID] = freq
-		err = se.badgerDB.SetObject("index:"+token, currentIndexData, BadgerTTL)
+		if vector != nil {
+			err = badgerdb.SetObject(txn, se.key("vector:"+docID), vector, ttl)
+			if err != nil {
+				return err
+			}
+		}
+		err = badgerdb.SetObject(txn, se.key("docTracker:"+docID), tracker, 0)
 		if err != nil {
-			log.Println(err)
+			return err
 		}
-	}
-
-	err := se.badgerDB.SetIntegers(BadgerTTL,
-		badgerdb.KVInt{Key: "docTokensLen:" + docID, Value: len(tokens)},
-		badgerdb.KVInt{Key: "tokenLen", Value: se.tokenLen},
-		badgerdb.KVInt{Key: "docCount", Value: se.docCount},
-	)
+		return badgerdb.SetObject(txn, se.key(expiryKey(tracker.ExpiresAt, docID)), nil, 0)
+	})
 	if err != nil {
-		log.Println(err)
+		return storageError(err)
 	}
 
-	if len(contents) > 0 {
-		data := map[string]interface{}{
-			"string": contents[0].String,
-			"object": contents[0].Object,
+	se.setCounters(counters)
+	se.vectors.Remove(docID)
+	if vector != nil {
+		if err = se.vectors.Add(docID, vector); err != nil {
+			return storageError(err)
 		}
-		err := se.badgerDB.SetObject("data:"+docID, data, BadgerTTL)
+	}
+	se.metrics.DocumentsIndexed.Inc()
+	return nil
+}
+
+// removeDocument reverts the postings and counters contributed by the tracked
+// version of docID in a single transaction. The caller must hold the write
+// lock.
+func (se *BadgerSearchEngine) removeDocument(docID string) error {
+	counters := se.counters()
+	err := se.badgerDB.DB.Update(func(txn *badger.Txn) error {
+		err := se.removeTracked(txn, docID, &counters)
 		if err != nil {
-			log.Println(err)
+			return err
 		}
+		return se.writeCounters(txn, counters)
+	})
+	if err != nil {
+		return storageError(err)
 	}
-}
 
-func (se *BadgerSearchEngine) Search(queries ...string) []structs.SearchResult {
-	se.mu.RLock()
-	defer se.mu.RUnlock()
+	se.setCounters(counters)
+	se.vectors.Remove(docID)
+	return nil
+}
 
-	if len(queries) == 0 {
+// removeTracked deletes the tracked version of docID inside txn and takes it
+// out of counters, which the caller writes. It stops at the first error.
+func (se *BadgerSearchEngine) removeTracked(txn *badger.Txn, docID string, counters *collectionCounters) error {
+	var tracker docTracker
+	err := badgerdb.GetObject(txn, se.key("docTracker:"+docID), &tracker)
+	if err != nil {
+		return err
+	}
+	if !tracker.isTracked() {
 		return nil
 	}
 
-	avgDocLen := se.calculateAvgDocLength()
-	docScores := make(map[string]float64)
+	for token := range tracker.Tokens {
+		termDocCount, err := badgerdb.GetInt(txn, se.key("termDocCount:"+token))
+		if err != nil {
+			return err
+		}
+		termDocCount--
+		if termDocCount > 0 {
+			err = badgerdb.SetInt(txn, se.key("termDocCount:"+token), termDocCount, BadgerTTL)
+		} else {
+			err = txn.Delete([]byte(se.key("termDocCount:" + token)))
+		}
+		if err != nil {
+			return err
+		}
 
-	for _, query := range queries {
-		var docFreqMap map[string]int
-		err := se.badgerDB.GetObject("index:"+query, &docFreqMap)
+		var currentIndexData map[string]int
+		err = badgerdb.GetObject(txn, se.key("index:"+token), &currentIndexData)
 		if err != nil {
-			return nil
+			return err
+		}
+		delete(currentIndexData, docID)
+		if len(currentIndexData) > 0 {
+			err = badgerdb.SetObject(txn, se.key("index:"+token), currentIndexData, BadgerTTL)
+		} else {
+			err = txn.Delete([]byte(se.key("index:" + token)))
+		}
+		if err != nil {
+			return err
 		}
 
-		if docFreqMap == nil {
+		if len(tracker.FieldLengths) == 0 {
 			continue
 		}
+		var currentFieldIndexData map[string]map[string]int
+		err = badgerdb.GetObject(txn, se.key("fieldIndex:"+token), &currentFieldIndexData)
+		if err != nil {
+			return err
+		}
+		delete(currentFieldIndexData, docID)
+		if len(currentFieldIndexData) > 0 {
+			err = badgerdb.SetObject(txn, se.key("fieldIndex:"+token), currentFieldIndexData, BadgerTTL)
+		} else {
+			err = txn.Delete([]byte(se.key("fieldIndex:" + token)))
+		}
+		if err != nil {
+			return err
+		}
+	}
 
-		for docID, tf := range docFreqMap {
-			docLen, err := se.badgerDB.GetInt("docTokensLen:" + docID)
-			if err != nil {
-				log.Println(err)
-			}
+	counters.tokenLen = max(counters.tokenLen-tracker.Length, 0)
+	counters.docCount = max(counters.docCount-1, 0)
+	for field, length := range tracker.FieldLengths {
+		counters.fieldTokenLen[field] -= length
+		if counters.fieldTokenLen[field] <= 0 {
+			delete(counters.fieldTokenLen, field)
+		}
+	}
 
-			termDocCount, err := se.badgerDB.GetInt("termDocCount:" + query)
-			if err != nil {
-				log.Println(err)
-			}
+	for _, key := range []string{
+		se.key("docTracker:" + docID),
+		se.key("docTokensLen:" + docID),
+		se.key("docFieldsLen:" + docID),
+		se.key("data:" + docID),
+		se.key("vector:" + docID),
+		se.key(expiryKey(tracker.ExpiresAt, docID)),
+	} {
+		if err = txn.Delete([]byte(key)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// reconcileExpired removes documents whose TTL has passed from the postings
+// they were listed in and from the collection statistics.
+func (se *BadgerSearchEngine) reconcileExpired() {
+	now := time.Now().Unix()
 
-			bm25Score := se.calculateBM25(tf, termDocCount, docLen, avgDocLen, se.k1, se.b)
-			docScores[docID] += bm25Score
+	var expiredKeys []string
+	err := se.badgerDB.IteratePrefix(se.key("expiry:"), func(key string, _ []byte) bool {
+		expiresAt, _, err := parseExpiryKey(strings.TrimPrefix(key, se.prefix))
+		if err != nil {
+			log.Println(err)
+			return true
+		}
+		if expiresAt > now {
+			return false
 		}
+		expiredKeys = append(expiredKeys, key)
+		return true
+	})
+	if err != nil {
+		log.Println(err)
+		return
 	}
 
-	results := make([]structs.SearchResult, 0, len(docScores))
-	for docID, score := range docScores {
-		results = append(results, structs.SearchResult{ID: docID, Score: score})
+	if len(expiredKeys) == 0 {
+		return
 	}
 
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
+	se.mu.Lock()
+	defer se.mu.Unlock()
 
-	if len(results) > 0 {
-		results = util.GetTopItems(results, 3)
-		for i, result := range results {
-			var value map[string]interface{}
-			err := se.badgerDB.GetObject("data:"+result.ID, &value)
+	if se.closed {
+		return
+	}
+	for _, key := range expiredKeys {
+		_, docID, _ := parseExpiryKey(strings.TrimPrefix(key, se.prefix))
+
+		var tracker docTracker
+		err := se.badgerDB.GetObject(se.key("docTracker:"+docID), &tracker)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if !tracker.isTracked() {
+			err = se.badgerDB.DeleteKey(key)
 			if err != nil {
 				log.Println(err)
-				continue
 			}
-			results[i].Data = value
+			continue
+		}
+		// The document may have been re-indexed since the scan.
+		if !tracker.isExpired(now) {
+			continue
+		}
+		if err = se.removeDocument(docID); err != nil {
+			log.Println(err)
+		}
+	}
+}
+
+func (se *BadgerSearchEngine) Search(ctx context.Context, queries ...string) (structs.SearchResponse, error) {
+	return se.SearchWithOptions(ctx, structs.SearchOptions{}, queries...)
+}
+
+func (se *BadgerSearchEngine) SearchWithOptions(ctx context.Context, options structs.SearchOptions, queries ...string) (structs.SearchResponse, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	se.metrics.Searches.Inc()
+	return se.searcher().search(ctx, options, queries)
+}
+
+func (se *BadgerSearchEngine) SearchQuery(ctx context.Context, q query.Query, options structs.SearchOptions) (structs.SearchResponse, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	se.metrics.Searches.Inc()
+	return se.searcher().runQuery(ctx, options, q)
+}
+
+func (se *BadgerSearchEngine) Count(ctx context.Context, options structs.SearchOptions, queries ...string) (int, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	se.metrics.Searches.Inc()
+	return se.searcher().count(ctx, options, queries)
+}
+
+func (se *BadgerSearchEngine) GetDocument(ctx context.Context, docID string) (structs.StoredDocument, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	return se.searcher().getDocument(ctx, docID)
+}
+
+func (se *BadgerSearchEngine) DocumentExists(ctx context.Context, docID string) (bool, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	return se.searcher().documentExists(ctx, docID)
+}
+
+func (se *BadgerSearchEngine) MoreLikeThis(ctx context.Context, docID string, maxQueryTerms int, options structs.SearchOptions) (structs.SearchResponse, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	se.metrics.Searches.Inc()
+	return se.searcher().moreLikeThis(ctx, docID, maxQueryTerms, options)
+}
+
+func (se *BadgerSearchEngine) searcher() searcher {
+	return searcher{reader: se, settings: se.settings, sim: se.sim, vectors: se.vectors}
+}
+
+func (se *BadgerSearchEngine) collectionStats(_ context.Context) (int, int, error) {
+	return se.tokenLen, se.docCount, nil
+}
+
+func (se *BadgerSearchEngine) fieldTokenLengths(_ context.Context) (map[string]int, error) {
+	return se.fieldTokenLen, nil
+}
+
+func (se *BadgerSearchEngine) postings(_ context.Context, token string) (map[string]int, error) {
+	se.metrics.PostingLookups.Inc()
+	var docFreqMap map[string]int
+	err := se.badgerDB.GetObject(se.key("index:"+token), &docFreqMap)
+	return docFreqMap, storageError(err)
+}
+
+func (se *BadgerSearchEngine) fieldPostings(_ context.Context, token string) (map[string]map[string]int, error) {
+	se.metrics.PostingLookups.Inc()
+	var fieldFreqMap map[string]map[string]int
+	err := se.badgerDB.GetObject(se.key("fieldIndex:"+token), &fieldFreqMap)
+	return fieldFreqMap, storageError(err)
+}
+
+func (se *BadgerSearchEngine) termDocCount(_ context.Context, token string) (int, error) {
+	termDocCount, err := se.badgerDB.GetInt(se.key("termDocCount:" + token))
+	return termDocCount, storageError(err)
+}
+
+func (se *BadgerSearchEngine) docLength(_ context.Context, docID string) (int, error) {
+	docLen, err := se.badgerDB.GetInt(se.key("docTokensLen:" + docID))
+	return docLen, storageError(err)
+}
+
+func (se *BadgerSearchEngine) docFieldLengths(_ context.Context, docID string) (map[string]int, error) {
+	var fieldLens map[string]int
+	err := se.badgerDB.GetObject(se.key("docFieldsLen:"+docID), &fieldLens)
+	return fieldLens, storageError(err)
+}
+
+func (se *BadgerSearchEngine) documentData(_ context.Context, docID string) (map[string]interface{}, error) {
+	var value map[string]interface{}
+	err := se.badgerDB.GetObject(se.key("data:"+docID), &value)
+	return value, storageError(err)
+}
+
+func (se *BadgerSearchEngine) getTracker(_ context.Context, docID string) (docTracker, error) {
+	var tracker docTracker
+	err := se.badgerDB.GetObject(se.key("docTracker:"+docID), &tracker)
+	return tracker, storageError(err)
+}
+
+func (se *BadgerSearchEngine) documentIDs(ctx context.Context) ([]string, error) {
+	now := time.Now().Unix()
+	trackerPrefix := se.key("docTracker:")
+
+	var docIDs []string
+	err := se.iteratePrefix(ctx, trackerPrefix, func(key string, value []byte) bool {
+		var tracker docTracker
+		err := json.Unmarshal(value, &tracker)
+		if err != nil {
+			log.Println(err)
+			return true
 		}
+		if !tracker.isExpired(now) {
+			docIDs = append(docIDs, strings.TrimPrefix(key, trackerPrefix))
+		}
+		return true
+	})
+	return docIDs, err
+}
+
+func (se *BadgerSearchEngine) termsWithPrefix(ctx context.Context, prefix string, limit int) ([]string, error) {
+	indexPrefix := se.key("index:")
+
+	var terms []string
+	err := se.iteratePrefix(ctx, indexPrefix+prefix, func(key string, value []byte) bool {
+		terms = append(terms, strings.TrimPrefix(key, indexPrefix))
+		return len(terms) < limit
+	})
+	return terms, err
+}
+
+// iteratePrefix is IteratePrefix stopping with the context error once ctx is
+// done.
+func (se *BadgerSearchEngine) iteratePrefix(ctx context.Context, prefix string, fn func(key string, value []byte) bool) error {
+	var ctxErr error
+	err := se.badgerDB.IteratePrefix(prefix, func(key string, value []byte) bool {
+		if ctxErr = ctx.Err(); ctxErr != nil {
+			return false
+		}
+		return fn(key, value)
+	})
+	if err == nil {
+		err = ctxErr
 	}
+	return storageError(err)
+}
+
+func (se *BadgerSearchEngine) ScanDocuments(ctx context.Context, fn func(doc structs.ExportedDocument) bool) error {
+	now := time.Now().Unix()
+	trackerPrefix := se.key("docTracker:")
 
-	return results
+	return se.iteratePrefix(ctx, trackerPrefix, func(key string, value []byte) bool {
+		var tracker docTracker
+		err := json.Unmarshal(value, &tracker)
+		if err != nil {
+			log.Println(err)
+			return true
+		}
+		if tracker.isExpired(now) {
+			return true
+		}
+
+		doc := structs.ExportedDocument{
+			ID:        strings.TrimPrefix(key, trackerPrefix),
+			Tokens:    tracker.tokenList(),
+			ExpiresAt: tracker.ExpiresAt,
+		}
+
+		var content *structs.Content
+		err = se.badgerDB.GetObject(se.key("data:"+doc.ID), &content)
+		if err != nil {
+			log.Println(err)
+		}
+		doc.Content = content
+
+		err = se.badgerDB.GetObject(se.key("vector:"+doc.ID), &doc.Vector)
+		if err != nil {
+			log.Println(err)
+		}
+
+		return fn(doc)
+	})
 }
 
 func (se *BadgerSearchEngine) GetPersistenceType() string {
 	return "BadgerDB"
 }
 
-func (se *BadgerSearchEngine) calculateAvgDocLength() int {
-	if se.docCount == 0 {
-		return 0
+func (se *BadgerSearchEngine) Reload() {
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	se.reload()
+}
+
+// reload reads the statistics and vectors from storage. The caller must hold
+// the write lock.
+func (se *BadgerSearchEngine) reload() {
+	se.tokenLen, se.docCount, se.fieldTokenLen = repopulateDataFromBadger(se.prefix, se.badgerDB)
+	se.vectors = loadVectorsFromBadger(se.prefix, se.badgerDB)
+}
+
+// pause stops the janitor and holds the write lock, so neither searches nor
+// writes run until resume reloads the engine from storage.
+func (se *BadgerSearchEngine) pause() (resume func()) {
+	se.stopJanitor()
+	se.mu.Lock()
+
+	return func() {
+		defer se.mu.Unlock()
+
+		if se.closed {
+			return
+		}
+		se.reload()
+		se.stopJanitor = startPeriodic(ExpiryJanitorInterval, se.reconcileExpired)
 	}
-	return se.tokenLen / se.docCount
 }
 
-func (se *BadgerSearchEngine) calculateBM25(tf, df, docLen, avgDocLen int, k1, b float64) float64 {
-	if df == 0 || avgDocLen == 0 {
-		return 0
+func (se *BadgerSearchEngine) Stats(_ context.Context) (structs.IndexStats, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	return structs.IndexStats{DocCount: se.docCount, TokenCount: se.tokenLen}, nil
+}
+
+// Close stops the janitor and persists the collection statistics once the
+// running write has finished.
+func (se *BadgerSearchEngine) Close() error {
+	se.stopJanitor()
+
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	if se.closed {
+		return nil
 	}
+	se.closed = true
 
-	idf := math.Log((float64(se.docCount)-float64(df)+0.5)/(float64(df)+0.5) + 1)
-	tfWeight := (float64(tf) * (k1 + 1)) / (float64(tf) + k1*(1-b+b*float64(docLen)/float64(avgDocLen)))
-	return idf * tfWeight
+	err := se.badgerDB.SetIntegers(BadgerTTL,
+		badgerdb.KVInt{Key: se.key("tokenLen"), Value: se.tokenLen},
+		badgerdb.KVInt{Key: se.key("docCount"), Value: se.docCount},
+	)
+	if err != nil {
+		return storageError(err)
+	}
+	return storageError(se.badgerDB.SetObject(se.key("fieldTokenLen"), se.fieldTokenLen, BadgerTTL))
 }
@@ -0,0 +1,79 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+func TestBadgerStoreDocumentReplaces(t *testing.T) {
+	se := newTestBadgerEngine(t)
+	ctx := context.Background()
+	if err := se.StoreDocument(ctx, "1", []string{"alpha", "beta"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := se.StoreDocument(ctx, "2", []string{"beta"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := se.StoreDocument(ctx, "1", []string{"beta", "beta", "gamma"}); err != nil {
+		t.Fatal(err)
+	}
+
+	for token, want := range map[string]map[string]int{
+		"alpha": nil,
+		"beta":  {"1": 2, "2": 1},
+		"gamma": {"1": 1},
+	} {
+		got, err := se.postings(ctx, token)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(got) != len(want) || (len(want) > 0 && !reflect.DeepEqual(got, want)) {
+			t.Errorf("postings of %s = %v, want %v", token, got, want)
+		}
+	}
+	if termDocCount, _ := se.termDocCount(ctx, "beta"); termDocCount != 2 {
+		t.Errorf("termDocCount of beta = %d, want 2", termDocCount)
+	}
+	if se.tokenLen != 4 || se.docCount != 2 {
+		t.Errorf("tokenLen, docCount = %d, %d, want 4, 2", se.tokenLen, se.docCount)
+	}
+}
+
+func TestBadgerStoreDocumentFailure(t *testing.T) {
+	se := newTestBadgerEngine(t)
+	ctx := context.Background()
+	err := se.StoreVectorDocument(ctx, "1", []string{"alpha"}, []float32{1, 0}, structs.Content{
+		Object:        map[string]interface{}{"name": "alpha"},
+		ObjectIndexes: []string{"name"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := se.counters()
+
+	// Every transaction fails once the database is closed.
+	if err = se.badgerDB.Close(); err != nil {
+		t.Fatal(err)
+	}
+	err = se.StoreVectorDocument(ctx, "2", []string{"alpha", "beta"}, []float32{0, 1}, structs.Content{
+		Object:        map[string]interface{}{"name": "alpha beta"},
+		ObjectIndexes: []string{"name"},
+	})
+	if !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("StoreVectorDocument() = %v, want ErrUnavailable", err)
+	}
+	if err = se.removeDocument("1"); !errors.Is(err, ErrUnavailable) {
+		t.Fatalf("removeDocument() = %v, want ErrUnavailable", err)
+	}
+
+	if got := se.counters(); !reflect.DeepEqual(got, want) {
+		t.Errorf("counters after the failed writes = %+v, want %+v", got, want)
+	}
+	if se.vectors.Len() != 1 {
+		t.Errorf("vectors after the failed writes = %d, want 1", se.vectors.Len())
+	}
+}
@@ -0,0 +1,89 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+// storedContent is the content kept under data: of a document. The object
+// indexes are kept so that an export can rebuild the field statistics.
+func storedContent(content structs.Content) map[string]interface{} {
+	return map[string]interface{}{
+		"string":         content.String,
+		"object":         content.Object,
+		"object_indexes": content.ObjectIndexes,
+	}
+}
+
+// getDocument reads a stored document with what its tracker knows about it.
+// The tracker decides whether the document exists; the data is nil for a
+// document stored without content.
+func (s searcher) getDocument(ctx context.Context, docID string) (structs.StoredDocument, error) {
+	tracker, err := s.liveTracker(ctx, docID)
+	if err != nil {
+		return structs.StoredDocument{}, err
+	}
+
+	data, err := s.reader.documentData(ctx, docID)
+	if err != nil {
+		return structs.StoredDocument{}, err
+	}
+	doc := structs.StoredDocument{
+		ID:          docID,
+		TokenLength: tracker.Length,
+		TTL:         tracker.ExpiresAt - time.Now().Unix(),
+	}
+	if data != nil {
+		doc.Data = data
+	}
+	return doc, nil
+}
+
+// documentExists reports whether docID is stored and live, reading only its
+// tracker.
+func (s searcher) documentExists(ctx context.Context, docID string) (bool, error) {
+	_, err := s.liveTracker(ctx, docID)
+	if errors.Is(err, ErrDocumentNotFound) {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// liveTracker returns the tracker of docID, or ErrDocumentNotFound when the
+// document does not exist or has expired.
+func (s searcher) liveTracker(ctx context.Context, docID string) (docTracker, error) {
+	tracker, err := s.reader.getTracker(ctx, docID)
+	if err != nil {
+		return docTracker{}, err
+	}
+	if !tracker.isTracked() || tracker.isExpired(time.Now().Unix()) {
+		return docTracker{}, ErrDocumentNotFound
+	}
+	return tracker, nil
+}
+
+// count returns the number of hits a search for the query terms has before
+// paging, without reading stored data unless min_score needs the function
+// score of the hits.
+func (s searcher) count(ctx context.Context, options structs.SearchOptions, queries []string) (int, error) {
+	if len(queries) == 0 {
+		return 0, nil
+	}
+
+	options.Explain = false
+	results, err := s.matchTerms(ctx, options, searchQuery{terms: queries}, nil)
+	if err != nil {
+		return 0, err
+	}
+	if options.MinScore > 0 {
+		documents := make(map[string]map[string]interface{})
+		results, err = s.adjustScores(ctx, options, results, documents, nil)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(results), nil
+}
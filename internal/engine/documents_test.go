@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+func newDocumentsReader() fakeReader {
+	reader := newFakeReader(map[string][]string{
+		"stored":  {"coffee", "laptop"},
+		"bare":    {"coffee"},
+		"expired": {"coffee", "laptop"},
+	}, map[string]map[string]interface{}{
+		"stored":  {"string": "coffee laptop"},
+		"expired": {"string": "coffee laptop"},
+	})
+	reader.expired["expired"] = true
+	return reader
+}
+
+func TestGetDocument(t *testing.T) {
+	s := newFakeSearcher(newDocumentsReader())
+	ctx := context.Background()
+
+	doc, err := s.getDocument(ctx, "stored")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.ID != "stored" || doc.TokenLength != 2 || doc.TTL <= 0 ||
+		!reflect.DeepEqual(doc.Data, map[string]interface{}{"string": "coffee laptop"}) {
+		t.Errorf("getDocument(stored) = %+v", doc)
+	}
+
+	doc, err = s.getDocument(ctx, "bare")
+	if err != nil {
+		t.Fatalf("getDocument() of a document without content = %v", err)
+	}
+	if doc.Data != nil || doc.TokenLength != 1 {
+		t.Errorf("getDocument(bare) = %+v, want nil data", doc)
+	}
+
+	for _, docID := range []string{"missing", "expired"} {
+		if _, err = s.getDocument(ctx, docID); !errors.Is(err, ErrDocumentNotFound) {
+			t.Errorf("getDocument(%s) = %v, want ErrDocumentNotFound", docID, err)
+		}
+	}
+}
+
+func TestDocumentExists(t *testing.T) {
+	reader := newDocumentsReader()
+	s := newFakeSearcher(reader)
+
+	for docID, want := range map[string]bool{"stored": true, "bare": true, "missing": false, "expired": false} {
+		got, err := s.documentExists(context.Background(), docID)
+		if err != nil || got != want {
+			t.Errorf("documentExists(%s) = %v, %v, want %v", docID, got, err, want)
+		}
+	}
+	if len(reader.reads) != 0 {
+		t.Errorf("documentExists() read %v, want only the trackers", reader.reads)
+	}
+}
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		name    string
+		options structs.SearchOptions
+		queries []string
+		want    int
+	}{
+		{name: "no terms", want: 0},
+		{name: "one term", queries: []string{"coffee"}, want: 2},
+		{name: "any term", queries: []string{"laptop", "missing"}, want: 2},
+		{
+			name:    "minimum_should_match",
+			options: structs.SearchOptions{MinimumShouldMatch: "2"},
+			queries: []string{"coffee", "laptop"},
+			want:    1,
+		},
+		{name: "ignores paging", options: structs.SearchOptions{Size: 1, From: 1}, queries: []string{"coffee"}, want: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := newFakeReader(map[string][]string{
+				"stored": {"coffee", "laptop"},
+				"bare":   {"coffee"},
+				"other":  {"laptop"},
+			}, nil)
+			got, err := newFakeSearcher(reader).count(context.Background(), tt.options, tt.queries)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("count() = %d, want %d", got, tt.want)
+			}
+			if len(reader.reads) != 0 {
+				t.Errorf("count() read %v, want no stored data", reader.reads)
+			}
+		})
+	}
+}
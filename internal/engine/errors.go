@@ -0,0 +1,57 @@
+package engine
+
+import (
+	"context"
+	"errors"
+)
+
+// The kinds of engine errors. Match them with errors.Is.
+var (
+	ErrInvalid     = errors.New("invalid request")
+	ErrNotFound    = errors.New("not found")
+	ErrUnavailable = errors.New("storage unavailable")
+	ErrTimeout     = errors.New("timeout")
+)
+
+// ErrClosed is returned by writes to an engine that has been closed.
+var ErrClosed = &Error{Kind: ErrUnavailable, Err: errors.New("search engine is closed")}
+
+// Error is an engine error of one kind. Its message is that of the cause,
+// and errors.Is matches both the kind and the cause.
+type Error struct {
+	Kind error
+	Err  error
+}
+
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() []error {
+	return []error{e.Kind, e.Err}
+}
+
+func invalidError(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &Error{Kind: ErrInvalid, Err: err}
+}
+
+// storageError classifies a storage failure: deadlines become timeouts and
+// everything else means the storage is unavailable. Errors that already
+// have a kind keep it, and cancelled requests get none.
+func storageError(err error) error {
+	var engineErr *Error
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &engineErr):
+		return err
+	case errors.Is(err, context.DeadlineExceeded):
+		return &Error{Kind: ErrTimeout, Err: err}
+	case errors.Is(err, context.Canceled):
+		return err
+	}
+	return &Error{Kind: ErrUnavailable, Err: err}
+}
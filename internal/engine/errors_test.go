@@ -0,0 +1,44 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestStorageError(t *testing.T) {
+	cause := errors.New("connection refused")
+	tests := []struct {
+		name      string
+		err       error
+		wantKind  error
+		wantCause error
+	}{
+		{name: "unavailable", err: cause, wantKind: ErrUnavailable, wantCause: cause},
+		{name: "deadline", err: fmt.Errorf("get: %w", context.DeadlineExceeded), wantKind: ErrTimeout, wantCause: context.DeadlineExceeded},
+		{name: "kept", err: invalidError(cause), wantKind: ErrInvalid, wantCause: cause},
+		{name: "joined", err: errors.Join(cause, ErrDocumentNotFound), wantKind: ErrNotFound, wantCause: cause},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := storageError(tt.err)
+			if !errors.Is(err, tt.wantKind) {
+				t.Errorf("storageError() = %v, want kind %v", err, tt.wantKind)
+			}
+			if !errors.Is(err, tt.wantCause) {
+				t.Errorf("storageError() = %v lost its cause", err)
+			}
+			if err.Error() != tt.err.Error() {
+				t.Errorf("storageError().Error() = %q, want %q", err.Error(), tt.err.Error())
+			}
+		})
+	}
+
+	if storageError(nil) != nil {
+		t.Error("storageError(nil) != nil")
+	}
+	if invalidError(nil) != nil {
+		t.Error("invalidError(nil) != nil")
+	}
+}
@@ -0,0 +1,107 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ExpiryJanitorInterval = 1 * time.Minute
+
+// docTracker records what a stored document contributed to the postings and
+// counters, so the contribution can be reverted once the document expires.
+// It is stored without TTL and outlives the document it describes.
+type docTracker struct {
+	ExpiresAt    int64          `json:"expires_at"`
+	Length       int            `json:"length"`
+	Tokens       map[string]int `json:"tokens"`
+	FieldLengths map[string]int `json:"field_lengths,omitempty"`
+}
+
+func (t docTracker) isTracked() bool {
+	return t.ExpiresAt > 0
+}
+
+func (t docTracker) isExpired(now int64) bool {
+	return t.ExpiresAt <= now
+}
+
+// tokenList expands the token frequencies back into a sorted token list.
+func (t docTracker) tokenList() []string {
+	tokens := make([]string, 0, t.Length)
+	for token, freq := range t.Tokens {
+		for i := 0; i < freq; i++ {
+			tokens = append(tokens, token)
+		}
+	}
+	sort.Strings(tokens)
+	return tokens
+}
+
+// expiryKey builds a time-ordered key so a prefix scan yields the documents
+// that expire first.
+func expiryKey(expiresAt int64, docID string) string {
+	return fmt.Sprintf("expiry:%020d:%s", expiresAt, docID)
+}
+
+func parseExpiryKey(key string) (int64, string, error) {
+	parts := strings.SplitN(strings.TrimPrefix(key, "expiry:"), ":", 2)
+	if len(parts) != 2 {
+		return 0, "", fmt.Errorf("invalid expiry key %q", key)
+	}
+	expiresAt, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid expiry key %q: %w", key, err)
+	}
+	return expiresAt, parts[1], nil
+}
+
+// importExpiry resolves when an imported document expires: when it expired
+// in the source, or ttl from now for exports that did not record it.
+func importExpiry(expiresAt int64, ttl time.Duration) (time.Time, error) {
+	if expiresAt == 0 {
+		return time.Now().Add(ttl), nil
+	}
+	at := time.Unix(expiresAt, 0)
+	if !at.After(time.Now()) {
+		return time.Time{}, invalidError(fmt.Errorf("document expired at %s", at.UTC().Format(time.RFC3339)))
+	}
+	return at, nil
+}
+
+// docTTLSeconds is the TTL of the keys of a document that expires at
+// expiresAt, rounded up so the keys are not gone before the document expires.
+func docTTLSeconds(expiresAt time.Time) int {
+	return int(math.Ceil(time.Until(expiresAt).Seconds()))
+}
+
+// startPeriodic calls run every interval until stop is called, e.g. to
+// reconcile expired documents. stop waits for a running call to finish.
+func startPeriodic(interval time.Duration, run func()) (stop func()) {
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				run()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+		<-stopped
+	}
+}
@@ -0,0 +1,157 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+	"github.com/dgraph-io/badger/v4"
+)
+
+func TestStartPeriodicStop(t *testing.T) {
+	var calls atomic.Int32
+	stop := startPeriodic(time.Millisecond, func() {
+		calls.Add(1)
+		time.Sleep(5 * time.Millisecond)
+	})
+	for calls.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	stop()
+	after := calls.Load()
+	time.Sleep(10 * time.Millisecond)
+	if calls.Load() != after {
+		t.Errorf("reconcile ran %d times after stop", calls.Load()-after)
+	}
+	stop()
+}
+
+func TestWriteGateClose(t *testing.T) {
+	var gate writeGate
+	if err := gate.enter(); err != nil {
+		t.Fatal(err)
+	}
+
+	closed := make(chan struct{})
+	go func() {
+		gate.close()
+		close(closed)
+	}()
+	select {
+	case <-closed:
+		t.Fatal("close returned before the running write left")
+	case <-time.After(10 * time.Millisecond):
+	}
+
+	gate.leave()
+	<-closed
+	if err := gate.enter(); !errors.Is(err, ErrClosed) || !errors.Is(err, ErrUnavailable) {
+		t.Errorf("enter() after close = %v, want ErrClosed", err)
+	}
+}
+
+// newTestBadgerDB opens a database in a temporary directory, closed when
+// the test ends.
+func newTestBadgerDB(t *testing.T) *badgerdb.BadgerDB {
+	t.Helper()
+	db := badgerdb.NewBadgerDB(config.BadgerConfig{Path: t.TempDir()})
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func newTestBadgerEngine(t *testing.T) *BadgerSearchEngine {
+	t.Helper()
+	se := newBadgerSearchEngine("", IndexSettings{BM25: config.BM25Config{K1: 1.5, B: 0.5}}, newTestBadgerDB(t))
+	t.Cleanup(func() { se.Close() })
+	return se
+}
+
+// keyExists reports whether key is live in the database of se.
+func keyExists(t *testing.T, se *BadgerSearchEngine, key string) bool {
+	t.Helper()
+	err := se.badgerDB.DB.View(func(txn *badger.Txn) error {
+		_, err := txn.Get([]byte(key))
+		return err
+	})
+	if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
+		t.Fatal(err)
+	}
+	return err == nil
+}
+
+// expireNow moves the expiry of a stored document into the past, as if its
+// TTL had passed, and returns the new expiry.
+func expireNow(t *testing.T, se *BadgerSearchEngine, docID string) int64 {
+	t.Helper()
+	tracker, err := se.getTracker(context.Background(), docID)
+	if err != nil || !tracker.isTracked() {
+		t.Fatalf("tracker of %q = %+v, %v", docID, tracker, err)
+	}
+	if err = se.badgerDB.DeleteKey(se.key(expiryKey(tracker.ExpiresAt, docID))); err != nil {
+		t.Fatal(err)
+	}
+	tracker.ExpiresAt = time.Now().Add(-time.Minute).Unix()
+	if err = se.badgerDB.SetObject(se.key("docTracker:"+docID), tracker, 0); err != nil {
+		t.Fatal(err)
+	}
+	if err = se.badgerDB.SetObject(se.key(expiryKey(tracker.ExpiresAt, docID)), nil, 0); err != nil {
+		t.Fatal(err)
+	}
+	return tracker.ExpiresAt
+}
+
+func TestBadgerReconcileExpired(t *testing.T) {
+	se := newTestBadgerEngine(t)
+	ctx := context.Background()
+	if err := se.StoreDocument(ctx, "expired", []string{"alpha", "beta", "beta"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := se.StoreDocument(ctx, "live", []string{"beta", "gamma"}); err != nil {
+		t.Fatal(err)
+	}
+	expiresAt := expireNow(t, se, "expired")
+
+	se.reconcileExpired()
+
+	if postings, _ := se.postings(ctx, "alpha"); len(postings) != 0 {
+		t.Errorf("postings of alpha = %v, want none", postings)
+	}
+	if postings, _ := se.postings(ctx, "beta"); len(postings) != 1 || postings["live"] != 1 {
+		t.Errorf("postings of beta = %v, want only live", postings)
+	}
+	for token, want := range map[string]int{"alpha": 0, "beta": 1, "gamma": 1} {
+		if got, _ := se.termDocCount(ctx, token); got != want {
+			t.Errorf("termDocCount of %s = %d, want %d", token, got, want)
+		}
+	}
+	if keyExists(t, se, se.key("termDocCount:alpha")) {
+		t.Error("termDocCount of alpha is still stored")
+	}
+
+	if se.tokenLen != 2 || se.docCount != 1 {
+		t.Errorf("tokenLen, docCount = %d, %d, want 2, 1", se.tokenLen, se.docCount)
+	}
+	tokenLen, _ := se.badgerDB.GetInt(se.key("tokenLen"))
+	docCount, _ := se.badgerDB.GetInt(se.key("docCount"))
+	if tokenLen != 2 || docCount != 1 {
+		t.Errorf("stored tokenLen, docCount = %d, %d, want 2, 1", tokenLen, docCount)
+	}
+
+	for _, key := range []string{
+		expiryKey(expiresAt, "expired"),
+		"docTracker:expired",
+		"docTokensLen:expired",
+	} {
+		if keyExists(t, se, se.key(key)) {
+			t.Errorf("%s is still stored", key)
+		}
+	}
+	if tracker, _ := se.getTracker(ctx, "live"); !tracker.isTracked() {
+		t.Error("the live document lost its tracker")
+	}
+}
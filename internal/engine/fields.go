@@ -0,0 +1,94 @@
+package engine
+
+import (
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/tokenizer"
+)
+
+// fieldTokenFrequency tokenizes the content field by field for BM25F. Only
+// tokens that were indexed for the document are kept, which drops the stop
+// words the caller removed. It returns the per-field frequencies of every
+// token and the token length of every field.
+func fieldTokenFrequency(tokenFrequency map[string]int, contents ...structs.Content) (map[string]map[string]int, map[string]int) {
+	fieldFrequency := make(map[string]map[string]int)
+	fieldLengths := make(map[string]int)
+	if len(contents) == 0 {
+		return fieldFrequency, fieldLengths
+	}
+
+	for field, tokens := range tokenizer.TokenizeFields(contents[0]) {
+		for _, token := range tokens {
+			if _, ok := tokenFrequency[token]; !ok {
+				continue
+			}
+			if fieldFrequency[token] == nil {
+				fieldFrequency[token] = make(map[string]int)
+			}
+			fieldFrequency[token][field]++
+			fieldLengths[field]++
+		}
+	}
+	return fieldFrequency, fieldLengths
+}
+
+// fieldWeights resolves the weight and length normalization of every field
+// for one BM25F search.
+type fieldWeights struct {
+	// restricted limits matching to the fields listed in the query.
+	restricted bool
+	fields     map[string]config.FieldConfig
+	defaultB   float64
+}
+
+// newFieldWeights combines the configured field weights with the query
+// boosts. It returns false when neither is set, in which case the index
+// similarity scores the whole document instead.
+func newFieldWeights(settings IndexSettings, boosts map[string]float64) (fieldWeights, bool) {
+	weights := fieldWeights{
+		fields:   make(map[string]config.FieldConfig),
+		defaultB: settings.BM25.B,
+	}
+	if len(boosts) == 0 && len(settings.BM25F.Fields) == 0 {
+		return weights, false
+	}
+
+	for field, fieldConfig := range settings.BM25F.Fields {
+		weights.fields[field] = weights.resolve(fieldConfig)
+	}
+
+	if len(boosts) > 0 {
+		weights.restricted = true
+		boosted := make(map[string]config.FieldConfig, len(boosts))
+		for field, boost := range boosts {
+			fieldConfig, ok := weights.fields[field]
+			if !ok {
+				fieldConfig = weights.resolve(config.FieldConfig{})
+			}
+			fieldConfig.Weight *= boost
+			boosted[field] = fieldConfig
+		}
+		weights.fields = boosted
+	}
+	return weights, true
+}
+
+func (w fieldWeights) resolve(fieldConfig config.FieldConfig) config.FieldConfig {
+	if fieldConfig.Weight == 0 {
+		fieldConfig.Weight = 1
+	}
+	if fieldConfig.B == 0 {
+		fieldConfig.B = w.defaultB
+	}
+	return fieldConfig
+}
+
+func (w fieldWeights) get(field string) (config.FieldConfig, bool) {
+	if fieldConfig, ok := w.fields[field]; ok {
+		return fieldConfig, true
+	}
+	if w.restricted {
+		return config.FieldConfig{}, false
+	}
+	return w.resolve(config.FieldConfig{}), true
+}
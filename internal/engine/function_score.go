@@ -0,0 +1,398 @@
+package engine
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+const (
+	ModeMultiply = "multiply"
+	ModeReplace  = "replace"
+	ModeSum      = "sum"
+	ModeAvg      = "avg"
+	ModeFirst    = "first"
+	ModeMax      = "max"
+	ModeMin      = "min"
+)
+
+const defaultDecay = 0.5
+
+// functionScorer is the validated form of a structs.FunctionScore.
+type functionScorer struct {
+	functions []weightedFunction
+	scoreMode string
+	boostMode string
+}
+
+type scoreFunction interface {
+	score(object map[string]interface{}) float64
+	explain(object map[string]interface{}) structs.Explanation
+}
+
+// weightedFunction multiplies fn by weight. A nil fn scores weight alone.
+type weightedFunction struct {
+	fn     scoreFunction
+	weight float64
+}
+
+func newFunctionScorer(functionScore *structs.FunctionScore, now time.Time) (*functionScorer, error) {
+	if len(functionScore.Functions) == 0 {
+		return nil, errors.New("function_score needs at least one function")
+	}
+
+	scorer := &functionScorer{
+		scoreMode: functionScore.ScoreMode,
+		boostMode: functionScore.BoostMode,
+	}
+	switch scorer.scoreMode {
+	case "":
+		scorer.scoreMode = ModeMultiply
+	case ModeMultiply, ModeSum, ModeAvg, ModeFirst, ModeMax, ModeMin:
+	default:
+		return nil, fmt.Errorf("unsupported score_mode %q: use multiply, sum, avg, first, max or min", scorer.scoreMode)
+	}
+	switch scorer.boostMode {
+	case "":
+		scorer.boostMode = ModeMultiply
+	case ModeMultiply, ModeReplace, ModeSum, ModeAvg, ModeMax, ModeMin:
+	default:
+		return nil, fmt.Errorf("unsupported boost_mode %q: use multiply, replace, sum, avg, max or min", scorer.boostMode)
+	}
+
+	for i, function := range functionScore.Functions {
+		fn, err := newScoreFunction(function, now)
+		if err != nil {
+			return nil, fmt.Errorf("function %d: %w", i, err)
+		}
+		scorer.functions = append(scorer.functions, fn)
+	}
+	return scorer, nil
+}
+
+func newScoreFunction(function structs.ScoreFunction, now time.Time) (weightedFunction, error) {
+	if function.Weight < 0 {
+		return weightedFunction{}, errors.New("weight must not be negative")
+	}
+	weighted := weightedFunction{weight: function.Weight}
+	if weighted.weight == 0 {
+		weighted.weight = 1
+	}
+
+	kinds := 0
+	var err error
+	if function.FieldValueFactor != nil {
+		kinds++
+		weighted.fn, err = newFieldValueFactor(*function.FieldValueFactor)
+	}
+	for kind, decay := range map[string]*structs.DecayFunction{"gauss": function.Gauss, "exp": function.Exp, "linear": function.Linear} {
+		if decay != nil {
+			kinds++
+			weighted.fn, err = newDecayFunction(kind, *decay, now)
+		}
+	}
+	switch {
+	case kinds > 1:
+		return weightedFunction{}, errors.New("set only one of field_value_factor, gauss, exp or linear")
+	case kinds == 0 && function.Weight == 0:
+		return weightedFunction{}, errors.New("set field_value_factor, gauss, exp, linear or weight")
+	}
+	return weighted, err
+}
+
+// apply combines the query score with the functions evaluated on the
+// document's object fields.
+func (f *functionScorer) apply(queryScore float64, object map[string]interface{}) float64 {
+	values := make([]float64, len(f.functions))
+	for i, function := range f.functions {
+		values[i] = function.score(object)
+	}
+	return combine(f.boostMode, []float64{queryScore, combine(f.scoreMode, values)})
+}
+
+func (f *functionScorer) explain(queryScore float64, query structs.Explanation, object map[string]interface{}) structs.Explanation {
+	functions := structs.Explanation{Description: fmt.Sprintf("functions, combined by score_mode %s", f.scoreMode)}
+	values := make([]float64, len(f.functions))
+	for i, function := range f.functions {
+		values[i] = function.score(object)
+		functions.Details = append(functions.Details, function.explain(object))
+	}
+	functions.Value = combine(f.scoreMode, values)
+
+	return structs.Explanation{
+		Value:       f.apply(queryScore, object),
+		Description: fmt.Sprintf("function score, combined by boost_mode %s", f.boostMode),
+		Details:     []structs.Explanation{query, functions},
+	}
+}
+
+func (w weightedFunction) score(object map[string]interface{}) float64 {
+	if w.fn == nil {
+		return w.weight
+	}
+	return w.weight * w.fn.score(object)
+}
+
+func (w weightedFunction) explain(object map[string]interface{}) structs.Explanation {
+	if w.fn == nil {
+		return explainParam(w.weight, "weight")
+	}
+	return structs.Explanation{
+		Value:       w.score(object),
+		Description: "weight * function",
+		Details:     []structs.Explanation{explainParam(w.weight, "weight"), w.fn.explain(object)},
+	}
+}
+
+func combine(mode string, values []float64) float64 {
+	result := values[0]
+	for _, value := range values[1:] {
+		switch mode {
+		case ModeMultiply:
+			result *= value
+		case ModeSum, ModeAvg:
+			result += value
+		case ModeReplace:
+			result = value
+		case ModeMax:
+			result = math.Max(result, value)
+		case ModeMin:
+			result = math.Min(result, value)
+		}
+	}
+	if mode == ModeAvg {
+		result /= float64(len(values))
+	}
+	return result
+}
+
+// fieldValueFactor scores modifier(factor * value). Modifiers that leave the
+// non-negative range (e.g. log of a value below 1) score 0.
+type fieldValueFactor struct {
+	field    string
+	factor   float64
+	modifier string
+	missing  *float64
+}
+
+func newFieldValueFactor(cfg structs.FieldValueFactor) (scoreFunction, error) {
+	if cfg.Field == "" {
+		return nil, errors.New("field_value_factor needs a field")
+	}
+
+	fn := fieldValueFactor{field: cfg.Field, factor: cfg.Factor, modifier: cfg.Modifier, missing: cfg.Missing}
+	if fn.factor == 0 {
+		fn.factor = 1
+	}
+	switch fn.modifier {
+	case "":
+		fn.modifier = "none"
+	case "none", "log", "log1p", "log2p", "ln", "ln1p", "ln2p", "sqrt", "square", "reciprocal":
+	default:
+		return nil, fmt.Errorf("unsupported modifier %q: use none, log, log1p, log2p, ln, ln1p, ln2p, sqrt, square or reciprocal", fn.modifier)
+	}
+	return fn, nil
+}
+
+func (f fieldValueFactor) value(object map[string]interface{}) (float64, bool) {
+	if value, ok := fieldNumber(object, f.field); ok {
+		return value, true
+	}
+	if f.missing != nil {
+		return *f.missing, true
+	}
+	return 0, false
+}
+
+func (f fieldValueFactor) score(object map[string]interface{}) float64 {
+	value, ok := f.value(object)
+	if !ok {
+		return 1
+	}
+
+	v := f.factor * value
+	var result float64
+	switch f.modifier {
+	case "log":
+		result = math.Log10(v)
+	case "log1p":
+		result = math.Log10(v + 1)
+	case "log2p":
+		result = math.Log10(v + 2)
+	case "ln":
+		result = math.Log(v)
+	case "ln1p":
+		result = math.Log1p(v)
+	case "ln2p":
+		result = math.Log(v + 2)
+	case "sqrt":
+		result = math.Sqrt(v)
+	case "square":
+		result = v * v
+	case "reciprocal":
+		result = 1 / v
+	default:
+		result = v
+	}
+	if math.IsNaN(result) || math.IsInf(result, 0) || result < 0 {
+		return 0
+	}
+	return result
+}
+
+func (f fieldValueFactor) explain(object map[string]interface{}) structs.Explanation {
+	value, ok := f.value(object)
+	if !ok {
+		return structs.Explanation{Value: 1, Description: fmt.Sprintf("field_value_factor, %s is missing", f.field)}
+	}
+	return structs.Explanation{
+		Value:       f.score(object),
+		Description: fmt.Sprintf("field_value_factor, computed as %s(factor * %s)", f.modifier, f.field),
+		Details:     []structs.Explanation{explainParam(f.factor, "factor"), explainParam(value, f.field)},
+	}
+}
+
+// decayFunction scores the distance of a field from origin with a gauss,
+// exp or linear curve.
+type decayFunction struct {
+	kind   string
+	field  string
+	origin float64
+	scale  float64
+	offset float64
+	decay  float64
+}
+
+func newDecayFunction(kind string, cfg structs.DecayFunction, now time.Time) (scoreFunction, error) {
+	if cfg.Field == "" {
+		return nil, fmt.Errorf("%s needs a field", kind)
+	}
+
+	fn := decayFunction{kind: kind, field: cfg.Field, decay: cfg.Decay}
+	var err error
+	fn.origin, err = parseDecayOrigin(cfg.Origin, now)
+	if err != nil {
+		return nil, fmt.Errorf("%s origin: %w", kind, err)
+	}
+	fn.scale, err = parseDecayDistance(cfg.Scale)
+	if err != nil || fn.scale <= 0 {
+		return nil, fmt.Errorf("%s scale must be a positive number or duration", kind)
+	}
+	if cfg.Offset != nil {
+		fn.offset, err = parseDecayDistance(cfg.Offset)
+		if err != nil || fn.offset < 0 {
+			return nil, fmt.Errorf("%s offset must be a non-negative number or duration", kind)
+		}
+	}
+	if fn.decay == 0 {
+		fn.decay = defaultDecay
+	}
+	if fn.decay <= 0 || fn.decay >= 1 {
+		return nil, fmt.Errorf("%s decay must be between 0 and 1", kind)
+	}
+	return fn, nil
+}
+
+func (f decayFunction) score(object map[string]interface{}) float64 {
+	value, ok := fieldNumber(object, f.field)
+	if !ok {
+		return 1
+	}
+
+	distance := math.Max(0, math.Abs(value-f.origin)-f.offset)
+	switch f.kind {
+	case "gauss":
+		sigmaSquared := -f.scale * f.scale / (2 * math.Log(f.decay))
+		return math.Exp(-distance * distance / (2 * sigmaSquared))
+	case "exp":
+		return math.Exp(math.Log(f.decay) / f.scale * distance)
+	default:
+		s := f.scale / (1 - f.decay)
+		return math.Max(0, (s-distance)/s)
+	}
+}
+
+func (f decayFunction) explain(object map[string]interface{}) structs.Explanation {
+	value, ok := fieldNumber(object, f.field)
+	if !ok {
+		return structs.Explanation{Value: 1, Description: fmt.Sprintf("%s decay, %s is missing", f.kind, f.field)}
+	}
+	return structs.Explanation{
+		Value:       f.score(object),
+		Description: fmt.Sprintf("%s decay of %s, computed from max(0, |value - origin| - offset)", f.kind, f.field),
+		Details: []structs.Explanation{
+			explainParam(value, "value"),
+			explainParam(f.origin, "origin"),
+			explainParam(f.offset, "offset"),
+			explainParam(f.scale, "scale"),
+			explainParam(f.decay, "decay"),
+		},
+	}
+}
+
+// fieldNumber reads a numeric object field. Strings holding a number or an
+// RFC 3339 date (as unix seconds) are accepted too.
+func fieldNumber(object map[string]interface{}, field string) (float64, bool) {
+	switch v := object[field].(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, true
+		}
+		if t, err := time.Parse(time.RFC3339, v); err == nil {
+			return float64(t.Unix()), true
+		}
+	}
+	return 0, false
+}
+
+func parseDecayOrigin(origin interface{}, now time.Time) (float64, error) {
+	switch v := origin.(type) {
+	case float64:
+		return v, nil
+	case string:
+		if v == "now" {
+			return float64(now.Unix()), nil
+		}
+		if value, ok := fieldNumber(map[string]interface{}{"origin": v}, "origin"); ok {
+			return value, nil
+		}
+	}
+	return 0, errors.New(`must be a number, an RFC 3339 date or "now"`)
+}
+
+// parseDecayDistance reads a number, or a duration in seconds. Durations
+// accept the time.ParseDuration units plus d (days) and w (weeks).
+func parseDecayDistance(distance interface{}) (float64, error) {
+	switch v := distance.(type) {
+	case float64:
+		return v, nil
+	case string:
+		if f, err := strconv.ParseFloat(v, 64); err == nil {
+			return f, nil
+		}
+		for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+			if n, err := strconv.ParseFloat(strings.TrimSuffix(v, suffix), 64); strings.HasSuffix(v, suffix) && err == nil {
+				return n * unit.Seconds(), nil
+			}
+		}
+		if d, err := time.ParseDuration(v); err == nil {
+			return d.Seconds(), nil
+		}
+	}
+	return 0, fmt.Errorf("invalid distance %v", distance)
+}
@@ -0,0 +1,125 @@
+package engine
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+func TestFunctionScorerApply(t *testing.T) {
+	now := time.Unix(1725261101, 0)
+	object := map[string]interface{}{
+		"total_amount": 500150.0,
+		"created_at":   float64(now.Unix() - 2*24*3600),
+		"confirmed_at": "2024-09-02T07:11:41Z",
+	}
+
+	tests := []struct {
+		name          string
+		functionScore structs.FunctionScore
+		want          float64
+	}{
+		{
+			name: "field value factor with log1p",
+			functionScore: structs.FunctionScore{Functions: []structs.ScoreFunction{
+				{FieldValueFactor: &structs.FieldValueFactor{Field: "total_amount", Modifier: "log1p"}},
+			}},
+			want: 2 * math.Log10(500151),
+		},
+		{
+			name: "missing field uses the missing value",
+			functionScore: structs.FunctionScore{Functions: []structs.ScoreFunction{
+				{FieldValueFactor: &structs.FieldValueFactor{Field: "amount", Factor: 2, Modifier: "sqrt", Missing: func() *float64 { v := 8.0; return &v }()}},
+			}},
+			want: 2 * 4,
+		},
+		{
+			name: "gauss decays to decay at scale",
+			functionScore: structs.FunctionScore{Functions: []structs.ScoreFunction{
+				{Gauss: &structs.DecayFunction{Field: "created_at", Origin: "now", Scale: "1d", Offset: "1d"}},
+			}},
+			want: 2 * 0.5,
+		},
+		{
+			name: "exp and linear decay on a date string summed with replace",
+			functionScore: structs.FunctionScore{
+				Functions: []structs.ScoreFunction{
+					{Exp: &structs.DecayFunction{Field: "confirmed_at", Origin: "2024-09-03T07:11:41Z", Scale: "1d", Decay: 0.25}},
+					{Linear: &structs.DecayFunction{Field: "total_amount", Origin: 500000.0, Scale: 300.0}},
+					{Weight: 3},
+				},
+				ScoreMode: ModeSum,
+				BoostMode: ModeReplace,
+			},
+			want: 0.25 + 0.75 + 3,
+		},
+		{
+			name: "missing decay field is neutral and boost_mode sum",
+			functionScore: structs.FunctionScore{
+				Functions: []structs.ScoreFunction{
+					{Gauss: &structs.DecayFunction{Field: "settled_at", Origin: 0.0, Scale: 1.0}, Weight: 2},
+				},
+				BoostMode: ModeSum,
+			},
+			want: 2 + 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scorer, err := newFunctionScorer(&tt.functionScore, now)
+			if err != nil {
+				t.Fatalf("newFunctionScorer() error = %v", err)
+			}
+			if got := scorer.apply(2, object); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("apply() = %v, want %v", got, tt.want)
+			}
+			if got := scorer.explain(2, structs.Explanation{Value: 2}, object).Value; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("explain().Value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewFunctionScorerInvalid(t *testing.T) {
+	tests := []struct {
+		name          string
+		functionScore structs.FunctionScore
+	}{
+		{name: "no functions"},
+		{
+			name: "unknown score mode",
+			functionScore: structs.FunctionScore{
+				Functions: []structs.ScoreFunction{{Weight: 1}},
+				ScoreMode: "median",
+			},
+		},
+		{
+			name: "two kinds in one function",
+			functionScore: structs.FunctionScore{Functions: []structs.ScoreFunction{{
+				FieldValueFactor: &structs.FieldValueFactor{Field: "amount"},
+				Gauss:            &structs.DecayFunction{Field: "amount", Origin: 0.0, Scale: 1.0},
+			}}},
+		},
+		{
+			name: "unknown modifier",
+			functionScore: structs.FunctionScore{Functions: []structs.ScoreFunction{
+				{FieldValueFactor: &structs.FieldValueFactor{Field: "amount", Modifier: "cube"}},
+			}},
+		},
+		{
+			name: "decay without scale",
+			functionScore: structs.FunctionScore{Functions: []structs.ScoreFunction{
+				{Exp: &structs.DecayFunction{Field: "created_at", Origin: "now"}},
+			}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newFunctionScorer(&tt.functionScore, time.Now()); err == nil {
+				t.Error("newFunctionScorer() expected an error")
+			}
+		})
+	}
+}
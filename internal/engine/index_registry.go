@@ -0,0 +1,366 @@
+package engine
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/metrics"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+	"github.com/go-redis/redis/v8"
+)
+
+// IndexSettings are the per-index options persisted alongside a named index.
+type IndexSettings struct {
+	BM25       config.BM25Config       `json:"bm25"`
+	Similarity config.SimilarityConfig `json:"similarity"`
+	BM25F      config.BM25FConfig      `json:"bm25f"`
+}
+
+func defaultIndexSettings(cfg *config.Config) IndexSettings {
+	return IndexSettings{BM25: cfg.BM25, Similarity: cfg.Similarity, BM25F: cfg.BM25F}
+}
+
+// withDefaults fills zero-valued options from defaults.
+func (s IndexSettings) withDefaults(defaults IndexSettings) IndexSettings {
+	if s.BM25.K1 == 0 {
+		s.BM25.K1 = defaults.BM25.K1
+	}
+	if s.BM25.B == 0 {
+		s.BM25.B = defaults.BM25.B
+	}
+	if s.Similarity.Model == "" {
+		s.Similarity = defaults.Similarity
+	}
+	if len(s.BM25F.Fields) == 0 {
+		s.BM25F = defaults.BM25F
+	}
+	return s
+}
+
+func (s IndexSettings) validate() error {
+	_, err := NewSimilarity(s.Similarity, s.BM25)
+	return err
+}
+
+// newSimilarity builds the configured model, falling back to BM25 for
+// settings that were stored before they could be validated.
+func (s IndexSettings) newSimilarity() Similarity {
+	sim, err := NewSimilarity(s.Similarity, s.BM25)
+	if err != nil {
+		log.Println(err)
+		return BM25Similarity{K1: s.BM25.K1, B: s.BM25.B}
+	}
+	return sim
+}
+
+// IndexRegistry keeps one search engine per named index. Every index lives in
+// its own key namespace, so postings, statistics and settings never mix.
+type IndexRegistry struct {
+	mu       sync.RWMutex
+	indexes  map[string]ISearchEngine
+	settings map[string]IndexSettings
+	defaults IndexSettings
+	configs  map[string]config.IndexConfig
+	catalog  indexCatalog
+	newIndex func(prefix string, settings IndexSettings) ISearchEngine
+}
+
+type indexCatalog interface {
+	save(name string, settings IndexSettings) error
+	load() (map[string]IndexSettings, error)
+}
+
+type settingsApplier interface {
+	applySettings(settings IndexSettings)
+}
+
+var indexNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+func NewIndexRegistry[T any](
+	persistenceType string,
+	cfg *config.Config,
+	db T) (*IndexRegistry, error) {
+
+	registry := &IndexRegistry{
+		indexes:  make(map[string]ISearchEngine),
+		settings: make(map[string]IndexSettings),
+		defaults: defaultIndexSettings(cfg),
+		configs:  cfg.Indexes,
+	}
+
+	switch persistenceType {
+	case "redis":
+		redisClient, ok := any(db).(*redis.Client)
+		if !ok {
+			return nil, fmt.Errorf("invalid type for Redis persistence")
+		}
+		registry.catalog = &redisIndexCatalog{redisDB: redisClient}
+		registry.newIndex = func(prefix string, settings IndexSettings) ISearchEngine {
+			return newRedisSearchEngine(prefix, settings, redisClient)
+		}
+	case "badger":
+		badgerDB, ok := any(db).(*badgerdb.BadgerDB)
+		if !ok {
+			return nil, fmt.Errorf("invalid type for BadgerDB persistence")
+		}
+		registry.catalog = &badgerIndexCatalog{badgerDB: badgerDB}
+		registry.newIndex = func(prefix string, settings IndexSettings) ISearchEngine {
+			return newBadgerSearchEngine(prefix, settings, badgerDB)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported persistence type: use redis or badger as search engine persistence")
+	}
+
+	stored, err := registry.catalog.load()
+	if err != nil {
+		return nil, err
+	}
+	for name, settings := range stored {
+		registry.indexes[name] = registry.newIndex(indexKeyPrefix(name), settings)
+		registry.settings[name] = settings
+	}
+
+	// The config wins over the stored settings of an index for the options
+	// its entry sets.
+	for name, indexConfig := range cfg.Indexes {
+		settings := registry.DefaultSettings(name)
+		if stored, ok := registry.settings[name]; ok {
+			settings = IndexSettings{
+				BM25:       indexConfig.BM25,
+				Similarity: indexConfig.Similarity,
+				BM25F:      indexConfig.BM25F,
+			}.withDefaults(stored)
+			if reflect.DeepEqual(settings, stored) {
+				continue
+			}
+			log.Printf("Applying the settings of index %q from config over the stored ones", name)
+		}
+		if _, err := registry.PutIndex(name, settings); err != nil {
+			return nil, fmt.Errorf("index %q from config: %w", name, err)
+		}
+	}
+
+	return registry, nil
+}
+
+// Stats returns the collection statistics of every index.
+func (r *IndexRegistry) Stats(ctx context.Context) (map[string]structs.IndexStats, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	stats := make(map[string]structs.IndexStats, len(r.indexes))
+	var errs []error
+	for name, se := range r.indexes {
+		s, err := se.Stats(ctx)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("index %q: %w", name, err))
+			continue
+		}
+		stats[name] = s
+	}
+	return stats, errors.Join(errs...)
+}
+
+// Close closes the search engine of every index.
+func (r *IndexRegistry) Close() error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var errs []error
+	for name, se := range r.indexes {
+		if err := se.Close(); err != nil {
+			errs = append(errs, fmt.Errorf("index %q: %w", name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func indexKeyPrefix(name string) string {
+	return "idx:" + name + ":"
+}
+
+// indexName is the name of the index with the key prefix, the one metrics
+// use for the default index when the prefix is empty.
+func indexName(prefix string) string {
+	if prefix == "" {
+		return metrics.DefaultIndex
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(prefix, "idx:"), ":")
+}
+
+// DefaultSettings returns the settings a new index with the given name starts
+// from: its index entry in the config, then the global defaults.
+func (r *IndexRegistry) DefaultSettings(name string) IndexSettings {
+	indexConfig, ok := r.configs[name]
+	if !ok {
+		return r.defaults
+	}
+	return IndexSettings{
+		BM25:       indexConfig.BM25,
+		Similarity: indexConfig.Similarity,
+		BM25F:      indexConfig.BM25F,
+	}.withDefaults(r.defaults)
+}
+
+// PutIndex creates the named index, or replaces the settings of an existing
+// one. An empty similarity or field weighting falls back to DefaultSettings.
+// k1 and b are taken as given, since zero is a valid value for both; callers
+// start from DefaultSettings to keep the defaults.
+func (r *IndexRegistry) PutIndex(name string, settings IndexSettings) (created bool, err error) {
+	if !indexNamePattern.MatchString(name) {
+		return false, invalidError(fmt.Errorf("invalid index name %q: use lowercase letters, digits, '-' or '_'", name))
+	}
+
+	bm25 := settings.BM25
+	settings = settings.withDefaults(r.DefaultSettings(name))
+	settings.BM25 = bm25
+	if err = settings.validate(); err != nil {
+		return false, invalidError(err)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err = r.catalog.save(name, settings)
+	if err != nil {
+		return false, storageError(err)
+	}
+	r.settings[name] = settings
+
+	if se, ok := r.indexes[name]; ok {
+		if applier, ok := se.(settingsApplier); ok {
+			applier.applySettings(settings)
+		}
+		return false, nil
+	}
+
+	r.indexes[name] = r.newIndex(indexKeyPrefix(name), settings)
+	return true, nil
+}
+
+// pause pauses every index and holds the registry lock until resume, which
+// resumes them and picks up the indexes of the restored catalog.
+func (r *IndexRegistry) pause() (resume func() error) {
+	r.mu.Lock()
+
+	resumes := make([]func(), 0, len(r.indexes))
+	for _, se := range r.indexes {
+		if p, ok := se.(pauser); ok {
+			resumes = append(resumes, p.pause())
+		}
+	}
+
+	return func() error {
+		defer r.mu.Unlock()
+
+		for _, resume := range resumes {
+			resume()
+		}
+		stored, err := r.catalog.load()
+		if err != nil {
+			return err
+		}
+		for name, settings := range stored {
+			r.settings[name] = settings
+			se, ok := r.indexes[name]
+			if !ok {
+				r.indexes[name] = r.newIndex(indexKeyPrefix(name), settings)
+				continue
+			}
+			if applier, ok := se.(settingsApplier); ok {
+				applier.applySettings(settings)
+			}
+		}
+		return nil
+	}
+}
+
+func (r *IndexRegistry) GetIndex(name string) (ISearchEngine, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	se, ok := r.indexes[name]
+	return se, ok
+}
+
+func (r *IndexRegistry) GetSettings(name string) (IndexSettings, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	settings, ok := r.settings[name]
+	return settings, ok
+}
+
+func (r *IndexRegistry) ListIndexes() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	names := make([]string, 0, len(r.indexes))
+	for name := range r.indexes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+type badgerIndexCatalog struct {
+	badgerDB *badgerdb.BadgerDB
+}
+
+func (c *badgerIndexCatalog) save(name string, settings IndexSettings) error {
+	return c.badgerDB.SetObject("indexes:"+name, settings, 0)
+}
+
+func (c *badgerIndexCatalog) load() (map[string]IndexSettings, error) {
+	stored := make(map[string]IndexSettings)
+	err := c.badgerDB.IteratePrefix("indexes:", func(key string, value []byte) bool {
+		var settings IndexSettings
+		if err := json.Unmarshal(value, &settings); err != nil {
+			log.Println(err)
+			return true
+		}
+		stored[strings.TrimPrefix(key, "indexes:")] = settings
+		return true
+	})
+	return stored, err
+}
+
+type redisIndexCatalog struct {
+	redisDB *redis.Client
+}
+
+func (c *redisIndexCatalog) save(name string, settings IndexSettings) error {
+	settingsBytes, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return c.redisDB.HSet(context.Background(), "indexes", name, settingsBytes).Err()
+}
+
+func (c *redisIndexCatalog) load() (map[string]IndexSettings, error) {
+	res, err := c.redisDB.HGetAll(context.Background(), "indexes").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	stored := make(map[string]IndexSettings, len(res))
+	for name, value := range res {
+		var settings IndexSettings
+		if err := json.Unmarshal([]byte(value), &settings); err != nil {
+			log.Println(err)
+			continue
+		}
+		stored[name] = settings
+	}
+	return stored, nil
+}
@@ -0,0 +1,81 @@
+package engine
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+)
+
+// newTestIndexRegistry opens a registry on db, closed when the test ends.
+func newTestIndexRegistry(t *testing.T, cfg *config.Config, db *badgerdb.BadgerDB) *IndexRegistry {
+	t.Helper()
+	registry, err := NewIndexRegistry("badger", cfg, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { registry.Close() })
+	return registry
+}
+
+func TestPutIndexSettings(t *testing.T) {
+	registry := newTestIndexRegistry(t, &config.Config{
+		BM25: config.BM25Config{K1: 1.2, B: 0.75},
+		Indexes: map[string]config.IndexConfig{
+			"orders": {BM25: config.BM25Config{B: 0.3}},
+		},
+	}, newTestBadgerDB(t))
+
+	if got := registry.DefaultSettings("orders").BM25; got != (config.BM25Config{K1: 1.2, B: 0.3}) {
+		t.Errorf("DefaultSettings(orders).BM25 = %+v, want the config entry over the defaults", got)
+	}
+
+	settings := registry.DefaultSettings("items")
+	settings.BM25.B = 0
+	if _, err := registry.PutIndex("items", settings); err != nil {
+		t.Fatal(err)
+	}
+	stored, _ := registry.GetSettings("items")
+	if stored.BM25 != (config.BM25Config{K1: 1.2, B: 0}) {
+		t.Errorf("settings of items = %+v, want the explicit b of 0", stored.BM25)
+	}
+	if stored.Similarity.Model != "" || len(stored.BM25F.Fields) != 0 {
+		t.Errorf("settings of items = %+v, want the defaults", stored)
+	}
+
+	for name, settings := range map[string]IndexSettings{
+		"Bad Name": {},
+		"items":    {Similarity: config.SimilarityConfig{Model: "unknown"}},
+	} {
+		if _, err := registry.PutIndex(name, settings); !errors.Is(err, ErrInvalid) {
+			t.Errorf("PutIndex(%q, %+v) = %v, want ErrInvalid", name, settings, err)
+		}
+	}
+}
+
+func TestNewIndexRegistryAppliesConfig(t *testing.T) {
+	db := newTestBadgerDB(t)
+	cfg := &config.Config{
+		BM25: config.BM25Config{K1: 1.2, B: 0.75},
+		Indexes: map[string]config.IndexConfig{
+			"orders": {BM25: config.BM25Config{B: 0.3}},
+		},
+	}
+
+	registry := newTestIndexRegistry(t, cfg, db)
+	_, err := registry.PutIndex("orders", IndexSettings{
+		BM25:       config.BM25Config{K1: 2, B: 0.9},
+		Similarity: config.SimilarityConfig{Model: SimilarityBM25Plus},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	registry.Close()
+
+	registry = newTestIndexRegistry(t, cfg, db)
+	settings, _ := registry.GetSettings("orders")
+	if settings.BM25 != (config.BM25Config{K1: 2, B: 0.3}) || settings.Similarity.Model != SimilarityBM25Plus {
+		t.Errorf("settings of orders = %+v, want b from config over the stored settings", settings)
+	}
+}
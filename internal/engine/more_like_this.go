@@ -0,0 +1,75 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+const DefaultMaxQueryTerms = 25
+
+var ErrDocumentNotFound = &Error{Kind: ErrNotFound, Err: errors.New("document not found")}
+
+// moreLikeThis searches for the most distinctive terms of a stored document,
+// weighted by tf-idf, leaving the document itself out. The terms are those
+// indexed for the document, as its tracker recorded them. Terms only the
+// source document contains cannot find anything similar and are skipped.
+func (s searcher) moreLikeThis(ctx context.Context, docID string, maxQueryTerms int, options structs.SearchOptions) (structs.SearchResponse, error) {
+	tracker, err := s.liveTracker(ctx, docID)
+	if err != nil {
+		return structs.SearchResponse{}, err
+	}
+	if maxQueryTerms <= 0 {
+		maxQueryTerms = DefaultMaxQueryTerms
+	}
+
+	_, docCount, err := s.reader.collectionStats(ctx)
+	if err != nil {
+		return structs.SearchResponse{}, err
+	}
+	type weightedTerm struct {
+		term   string
+		weight float64
+	}
+	terms := make([]weightedTerm, 0, len(tracker.Tokens))
+	for term, tf := range tracker.Tokens {
+		df, err := s.reader.termDocCount(ctx, term)
+		if err != nil {
+			return structs.SearchResponse{}, err
+		}
+		if df <= 1 {
+			continue
+		}
+		idf := math.Log(float64(docCount+1) / float64(df+1))
+		terms = append(terms, weightedTerm{term: term, weight: float64(tf) * idf})
+	}
+
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].weight != terms[j].weight {
+			return terms[i].weight > terms[j].weight
+		}
+		return terms[i].term < terms[j].term
+	})
+	if len(terms) > maxQueryTerms {
+		terms = terms[:maxQueryTerms]
+	}
+	if len(terms) == 0 || terms[0].weight <= 0 {
+		return structs.SearchResponse{}, nil
+	}
+
+	query := searchQuery{
+		boosts:  make(map[string]float64, len(terms)),
+		exclude: docID,
+	}
+	for _, term := range terms {
+		if term.weight <= 0 {
+			break
+		}
+		query.terms = append(query.terms, term.term)
+		query.boosts[term.term] = term.weight / terms[0].weight
+	}
+	return s.run(ctx, options, query)
+}
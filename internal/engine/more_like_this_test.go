@@ -0,0 +1,46 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+func TestMoreLikeThis(t *testing.T) {
+	// The source has no stored content: its terms come from its tracker.
+	s := newFakeSearcher(newFakeReader(map[string][]string{
+		"source": {"alpha", "alpha", "beta", "gamma", "unique"},
+		"a":      {"alpha", "beta"},
+		"b":      {"gamma"},
+		"c":      {"delta"},
+	}, nil))
+
+	tests := []struct {
+		name          string
+		maxQueryTerms int
+		want          []string
+	}{
+		// alpha weighs most, beta wins the tie with gamma by name, unique
+		// only matches the source.
+		{name: "best terms", maxQueryTerms: 2, want: []string{"a"}},
+		{name: "default max terms", maxQueryTerms: 0, want: []string{"a", "b"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			response, err := s.moreLikeThis(context.Background(), "source", tt.maxQueryTerms, structs.SearchOptions{})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := hitIDs(response.Hits); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("moreLikeThis() hits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := s.moreLikeThis(context.Background(), "missing", 0, structs.SearchOptions{}); !errors.Is(err, ErrDocumentNotFound) {
+		t.Errorf("moreLikeThis() of a missing document = %v, want ErrDocumentNotFound", err)
+	}
+}
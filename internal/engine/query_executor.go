@@ -0,0 +1,534 @@
+package engine
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"github.com/ahmadrezamusthafa/search-engine/internal/query"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/tokenizer"
+)
+
+// maxPrefixExpansions caps the number of indexed terms a prefix query
+// expands to.
+const maxPrefixExpansions = 50
+
+// scoredDoc is a document matched by a query node with its score and, when
+// explaining, how the score was computed.
+type scoredDoc struct {
+	score       float64
+	explanation structs.Explanation
+}
+
+type scoredDocs map[string]scoredDoc
+
+func (docs scoredDocs) ids() []string {
+	ids := make([]string, 0, len(docs))
+	for docID := range docs {
+		ids = append(ids, docID)
+	}
+	return ids
+}
+
+// queryExecutor evaluates a query tree. Match and prefix queries read the
+// postings; term and range queries check the stored data of the documents
+// the other clauses found, and only scan the whole index when nothing else
+// narrows the documents down.
+type queryExecutor struct {
+	s         searcher
+	explain   bool
+	now       time.Time
+	documents map[string]map[string]interface{}
+	// allDocs caches the live document IDs once a query needed them.
+	allDocs []string
+}
+
+func (e *queryExecutor) execute(ctx context.Context, q query.Query) (scoredDocs, error) {
+	switch q := q.(type) {
+	case query.Bool:
+		return e.executeBool(ctx, q)
+	case query.Match:
+		return e.executeMatch(ctx, q)
+	case query.Term:
+		candidates, err := e.termCandidates(ctx, q)
+		if err != nil {
+			return nil, err
+		}
+		return e.verify(ctx, q, candidates)
+	case query.Range:
+		candidates, err := e.allDocuments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		return e.verify(ctx, q, candidates)
+	case query.Prefix:
+		return e.executePrefix(ctx, q)
+	case query.MatchAll:
+		candidates, err := e.allDocuments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		docs := make(scoredDocs, len(candidates))
+		for _, docID := range candidates {
+			docs[docID] = e.constantScore(q.Boost, "match_all")
+		}
+		return docs, nil
+	}
+	return nil, invalidError(fmt.Errorf("unsupported query %T", q))
+}
+
+// isVerifiable reports whether the query can check given documents without
+// reading the postings.
+func isVerifiable(q query.Query) bool {
+	switch q.(type) {
+	case query.Term, query.Range:
+		return true
+	}
+	return false
+}
+
+// verify returns the candidates matching a term or range query.
+func (e *queryExecutor) verify(ctx context.Context, q query.Query, candidates []string) (scoredDocs, error) {
+	var (
+		matches     func(data map[string]interface{}) bool
+		boost       float64
+		description string
+	)
+
+	switch q := q.(type) {
+	case query.Term:
+		want := util.InterfaceToString(q.Value)
+		matches = func(data map[string]interface{}) bool {
+			value := documentField(data, q.Field)
+			return value != nil && util.InterfaceToString(value) == want
+		}
+		boost, description = q.Boost, fmt.Sprintf("term(%s:%s)", q.Field, want)
+	case query.Range:
+		bounds, err := newRangeBounds(q, e.now)
+		if err != nil {
+			return nil, err
+		}
+		matches = func(data map[string]interface{}) bool {
+			value, ok := fieldNumber(map[string]interface{}{q.Field: documentField(data, q.Field)}, q.Field)
+			return ok && bounds.contains(value)
+		}
+		boost, description = q.Boost, fmt.Sprintf("range(%s)", q.Field)
+	default:
+		return nil, invalidError(fmt.Errorf("cannot verify query %T", q))
+	}
+
+	docs := make(scoredDocs)
+	for _, docID := range candidates {
+		data, err := e.document(ctx, docID)
+		if err != nil {
+			return nil, err
+		}
+		if data != nil && matches(data) {
+			docs[docID] = e.constantScore(boost, description)
+		}
+	}
+	return docs, nil
+}
+
+// executeBool intersects the required clauses, adds the should clauses and
+// removes the documents matching a must_not clause.
+func (e *queryExecutor) executeBool(ctx context.Context, q query.Bool) (scoredDocs, error) {
+	type clause struct {
+		q       query.Query
+		scoring bool
+	}
+	required := make([]clause, 0, len(q.Must)+len(q.Filter))
+	for _, must := range q.Must {
+		required = append(required, clause{q: must, scoring: true})
+	}
+	for _, filter := range q.Filter {
+		required = append(required, clause{q: filter})
+	}
+	// Clauses reading the postings find the candidates, so the verifiable
+	// ones only check those.
+	sort.SliceStable(required, func(i, j int) bool {
+		return !isVerifiable(required[i].q) && isVerifiable(required[j].q)
+	})
+
+	// candidates stays nil until a clause has constrained the documents.
+	var candidates scoredDocs
+	for _, c := range required {
+		matched, err := e.match(ctx, c.q, candidates)
+		if err != nil {
+			return nil, err
+		}
+		if candidates == nil {
+			candidates = make(scoredDocs, len(matched))
+			for docID := range matched {
+				candidates[docID] = scoredDoc{}
+			}
+		}
+
+		for docID, doc := range candidates {
+			match, ok := matched[docID]
+			if !ok {
+				delete(candidates, docID)
+				continue
+			}
+			if c.scoring {
+				candidates[docID] = e.add(doc, match)
+			}
+		}
+		if len(candidates) == 0 {
+			return candidates, nil
+		}
+	}
+
+	if len(q.Should) > 0 {
+		requiredShould := 0
+		if candidates == nil {
+			requiredShould = 1
+		}
+		if q.MinimumShouldMatch != "" {
+			var err error
+			requiredShould, err = minimumShouldMatch(q.MinimumShouldMatch, len(q.Should))
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		constrained := candidates != nil
+		if !constrained {
+			candidates = make(scoredDocs)
+		}
+		matchedClauses := make(map[string]int)
+		for _, should := range q.Should {
+			var matched scoredDocs
+			var err error
+			if constrained {
+				matched, err = e.match(ctx, should, candidates)
+			} else {
+				matched, err = e.execute(ctx, should)
+			}
+			if err != nil {
+				return nil, err
+			}
+			for docID, match := range matched {
+				doc, ok := candidates[docID]
+				if !ok && constrained {
+					continue
+				}
+				candidates[docID] = e.add(doc, match)
+				matchedClauses[docID]++
+			}
+		}
+		for docID := range candidates {
+			if matchedClauses[docID] < requiredShould {
+				delete(candidates, docID)
+			}
+		}
+	}
+
+	if candidates == nil {
+		all, err := e.allDocuments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		candidates = make(scoredDocs, len(all))
+		for _, docID := range all {
+			candidates[docID] = scoredDoc{}
+		}
+	}
+
+	for _, mustNot := range q.MustNot {
+		if len(candidates) == 0 {
+			break
+		}
+		matched, err := e.match(ctx, mustNot, candidates)
+		if err != nil {
+			return nil, err
+		}
+		for docID := range matched {
+			delete(candidates, docID)
+		}
+	}
+
+	for docID, doc := range candidates {
+		if e.explain {
+			doc.explanation = structs.Explanation{Value: doc.score, Description: "sum of:", Details: doc.explanation.Details}
+		}
+		candidates[docID] = e.boost(doc, q.Boost)
+	}
+	return candidates, nil
+}
+
+// match runs q, or only checks the candidates when q is verifiable and
+// candidates is not nil.
+func (e *queryExecutor) match(ctx context.Context, q query.Query, candidates scoredDocs) (scoredDocs, error) {
+	if candidates != nil && isVerifiable(q) {
+		return e.verify(ctx, q, candidates.ids())
+	}
+	return e.execute(ctx, q)
+}
+
+// executeMatch scores the query text like a query parameter search, on one
+// field or, for _all, on the whole document.
+func (e *queryExecutor) executeMatch(ctx context.Context, q query.Match) (scoredDocs, error) {
+	terms := tokenizer.TokenizeQuery(q.Query)
+	if len(terms) == 0 {
+		return scoredDocs{}, nil
+	}
+
+	requiredTerms := countTerms(terms)
+	if q.Operator != "and" {
+		var err error
+		requiredTerms, err = minimumShouldMatch(q.MinimumShouldMatch, requiredTerms)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sq := searchQuery{terms: terms}
+	if q.Boost != 0 && q.Boost != 1 {
+		sq.boosts = make(map[string]float64, len(terms))
+		for _, term := range terms {
+			sq.boosts[term] = q.Boost
+		}
+	}
+
+	var boosts map[string]float64
+	if q.Field != query.AllFields {
+		boosts = map[string]float64{q.Field: 1}
+	}
+
+	matches := newTermMatches(e.explain)
+	var err error
+	if weights, isBM25F := newFieldWeights(e.s.settings, boosts); isBM25F {
+		err = e.s.scoreBM25F(ctx, sq, weights, matches)
+	} else {
+		err = e.s.scoreTerms(ctx, sq, matches)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	docs := make(scoredDocs, len(matches.scores))
+	for docID, score := range matches.scores {
+		if matches.matchedTerms[docID] < requiredTerms {
+			continue
+		}
+		doc := scoredDoc{score: score}
+		if e.explain {
+			doc.explanation = structs.Explanation{Value: score, Description: "sum of:", Details: matches.explanations[docID]}
+		}
+		docs[docID] = doc
+	}
+	return docs, nil
+}
+
+// executePrefix matches the documents containing one of the indexed terms
+// starting with the prefix, with a constant score.
+func (e *queryExecutor) executePrefix(ctx context.Context, q query.Prefix) (scoredDocs, error) {
+	terms, err := e.s.reader.termsWithPrefix(ctx, q.Value, maxPrefixExpansions)
+	if err != nil {
+		return nil, err
+	}
+
+	description := fmt.Sprintf("prefix(%s:%s)", q.Field, q.Value)
+	docs := make(scoredDocs)
+	for _, term := range terms {
+		expired, err := e.s.timer.check(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if expired {
+			break
+		}
+
+		if q.Field == query.AllFields {
+			postings, err := e.s.reader.postings(ctx, term)
+			if err != nil {
+				return nil, err
+			}
+			for docID := range postings {
+				docs[docID] = e.constantScore(q.Boost, description)
+			}
+			continue
+		}
+
+		fieldPostings, err := e.s.reader.fieldPostings(ctx, term)
+		if err != nil {
+			return nil, err
+		}
+		for docID, fieldFreqs := range fieldPostings {
+			if fieldFreqs[q.Field] > 0 {
+				docs[docID] = e.constantScore(q.Boost, description)
+			}
+		}
+	}
+	return docs, nil
+}
+
+// termCandidates finds the documents whose field holds every token of the
+// term value. A value without tokens, e.g. only stop words, can only be
+// checked against every document.
+func (e *queryExecutor) termCandidates(ctx context.Context, q query.Term) ([]string, error) {
+	tokens := tokenizer.TokenizeQuery(util.InterfaceToString(q.Value))
+	if len(tokens) == 0 {
+		return e.allDocuments(ctx)
+	}
+
+	// The candidates are verified against the stored value, so when the
+	// search times out those found for the first tokens will do.
+	var candidates map[string]bool
+	for _, token := range tokens {
+		expired, err := e.s.timer.check(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if expired {
+			break
+		}
+
+		fieldPostings, err := e.s.reader.fieldPostings(ctx, token)
+		if err != nil {
+			return nil, err
+		}
+
+		found := make(map[string]bool)
+		for docID, fieldFreqs := range fieldPostings {
+			if fieldFreqs[q.Field] > 0 && (candidates == nil || candidates[docID]) {
+				found[docID] = true
+			}
+		}
+		candidates = found
+		if len(candidates) == 0 {
+			return nil, nil
+		}
+	}
+
+	ids := make([]string, 0, len(candidates))
+	for docID := range candidates {
+		ids = append(ids, docID)
+	}
+	return ids, nil
+}
+
+func (e *queryExecutor) allDocuments(ctx context.Context) ([]string, error) {
+	if e.allDocs == nil {
+		ids, err := e.s.reader.documentIDs(ctx)
+		if err != nil {
+			return nil, err
+		}
+		e.allDocs = ids
+	}
+	return e.allDocs, nil
+}
+
+func (e *queryExecutor) document(ctx context.Context, docID string) (map[string]interface{}, error) {
+	data, ok := e.documents[docID]
+	if !ok {
+		var err error
+		data, err = e.s.reader.documentData(ctx, docID)
+		if err != nil {
+			return nil, err
+		}
+		e.documents[docID] = data
+	}
+	return data, nil
+}
+
+func (e *queryExecutor) constantScore(boost float64, description string) scoredDoc {
+	if boost == 0 {
+		boost = 1
+	}
+	doc := scoredDoc{score: boost}
+	if e.explain {
+		doc.explanation = structs.Explanation{Value: boost, Description: description + ", constant score"}
+	}
+	return doc
+}
+
+// add sums a clause match into doc.
+func (e *queryExecutor) add(doc, match scoredDoc) scoredDoc {
+	doc.score += match.score
+	if e.explain {
+		doc.explanation.Details = append(doc.explanation.Details, match.explanation)
+	}
+	return doc
+}
+
+func (e *queryExecutor) boost(doc scoredDoc, boost float64) scoredDoc {
+	if boost == 0 || boost == 1 {
+		return doc
+	}
+	doc.score *= boost
+	if e.explain {
+		doc.explanation = structs.Explanation{
+			Value:       doc.score,
+			Description: "product of:",
+			Details:     []structs.Explanation{doc.explanation, explainParam(boost, "boost")},
+		}
+	}
+	return doc
+}
+
+// rangeBounds holds the resolved bounds of a range query; nil bounds are
+// open.
+type rangeBounds struct {
+	gt, gte, lt, lte *float64
+}
+
+func newRangeBounds(q query.Range, now time.Time) (rangeBounds, error) {
+	var bounds rangeBounds
+	for _, bound := range []struct {
+		value  interface{}
+		target **float64
+	}{{q.GT, &bounds.gt}, {q.GTE, &bounds.gte}, {q.LT, &bounds.lt}, {q.LTE, &bounds.lte}} {
+		if bound.value == nil {
+			continue
+		}
+		value, err := parseDecayOrigin(bound.value, now)
+		if err != nil {
+			return bounds, invalidError(fmt.Errorf("invalid range on %s: bounds %w", q.Field, err))
+		}
+		*bound.target = &value
+	}
+	return bounds, nil
+}
+
+func (b rangeBounds) contains(value float64) bool {
+	return (b.gt == nil || value > *b.gt) &&
+		(b.gte == nil || value >= *b.gte) &&
+		(b.lt == nil || value < *b.lt) &&
+		(b.lte == nil || value <= *b.lte)
+}
+
+// documentField returns an object field of stored document data, or the
+// string content for the string field.
+func documentField(data map[string]interface{}, field string) interface{} {
+	if value, ok := documentObject(data)[field]; ok {
+		return value
+	}
+	if field == tokenizer.StringField {
+		return data["string"]
+	}
+	return nil
+}
+
+// queryTerms collects the terms of the match and term queries of the tree,
+// for the rescorers.
+func queryTerms(q query.Query) []string {
+	var terms []string
+	switch q := q.(type) {
+	case query.Bool:
+		for _, clauses := range [][]query.Query{q.Must, q.Should, q.Filter} {
+			for _, clause := range clauses {
+				terms = append(terms, queryTerms(clause)...)
+			}
+		}
+	case query.Match:
+		terms = tokenizer.TokenizeQuery(q.Query)
+	case query.Term:
+		terms = tokenizer.TokenizeQuery(util.InterfaceToString(q.Value))
+	}
+	return terms
+}
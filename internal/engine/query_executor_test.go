@@ -0,0 +1,172 @@
+package engine
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/query"
+)
+
+func executorDocuments() map[string]map[string]interface{} {
+	doc := func(text, sender, city string, amount float64) map[string]interface{} {
+		return map[string]interface{}{
+			"string": text,
+			"object": map[string]interface{}{"sender": sender, "city": city, "amount": amount},
+		}
+	}
+	return map[string]map[string]interface{}{
+		"1": doc("coffee to budi", "reza", "jakarta", 100),
+		"2": doc("coffee laptop", "budi", "bandung", 250),
+		"3": doc("rent", "reza", "bandung", 500),
+		"4": doc("rent laptop", "andi", "jakarta", 50),
+	}
+}
+
+func TestQueryExecutorExecute(t *testing.T) {
+	match := func(text string) query.Match { return query.Match{Field: query.AllFields, Query: text} }
+	term := func(field string, value interface{}) query.Term { return query.Term{Field: field, Value: value} }
+
+	tests := []struct {
+		name  string
+		query query.Query
+		want  []string
+		// fullScan is whether the live documents were listed.
+		fullScan bool
+		// dataReads lists the documents whose data was read, unchecked
+		// when nil.
+		dataReads []string
+	}{
+		{name: "match", query: match("coffee"), want: []string{"1", "2"}, dataReads: []string{}},
+		{name: "match in a field", query: query.Match{Field: "sender", Query: "budi"}, want: []string{"2"}},
+		{name: "match without terms", query: match("to"), want: []string{}},
+		{name: "term", query: term("sender", "reza"), want: []string{"1", "3"}, dataReads: []string{"1", "3"}},
+		{name: "term on a number", query: term("amount", 250), want: []string{"2"}},
+		{name: "range", query: query.Range{Field: "amount", GTE: 200.0}, want: []string{"2", "3"}, fullScan: true},
+		{name: "match_all", query: query.MatchAll{}, want: []string{"1", "2", "3", "4"}, fullScan: true},
+		{
+			name:  "prefix expands to every indexed term",
+			query: query.Prefix{Field: query.AllFields, Value: "b"},
+			want:  []string{"1", "2", "3"},
+		},
+		{name: "prefix in a field", query: query.Prefix{Field: "sender", Value: "b"}, want: []string{"2"}},
+		{
+			name:      "must narrows the filter candidates",
+			query:     query.Bool{Must: []query.Query{match("coffee")}, Filter: []query.Query{term("city", "jakarta")}},
+			want:      []string{"1"},
+			dataReads: []string{"1", "2"},
+		},
+		{
+			name:      "range only checks the candidates",
+			query:     query.Bool{Filter: []query.Query{query.Range{Field: "amount", GTE: 200.0}, match("coffee")}},
+			want:      []string{"2"},
+			dataReads: []string{"1", "2"},
+		},
+		{
+			name: "must_not removes documents",
+			query: query.Bool{
+				Filter:  []query.Query{query.Range{Field: "amount", LT: 300.0}},
+				MustNot: []query.Query{term("sender", "budi")},
+			},
+			want:     []string{"1", "4"},
+			fullScan: true,
+		},
+		{
+			name:     "must_not only scans every document",
+			query:    query.Bool{MustNot: []query.Query{term("city", "jakarta")}},
+			want:     []string{"2", "3"},
+			fullScan: true,
+		},
+		{
+			name:      "empty candidates skip the other clauses",
+			query:     query.Bool{Must: []query.Query{match("missing")}, Filter: []query.Query{term("city", "jakarta")}, MustNot: []query.Query{match("laptop")}},
+			want:      []string{},
+			dataReads: []string{},
+		},
+		{
+			name:  "should without required clauses needs one match",
+			query: query.Bool{Should: []query.Query{term("city", "bandung"), match("rent")}},
+			want:  []string{"2", "3", "4"},
+		},
+		{
+			name: "minimum_should_match",
+			query: query.Bool{
+				Should:             []query.Query{term("city", "bandung"), match("rent"), term("sender", "reza")},
+				MinimumShouldMatch: "2",
+			},
+			want: []string{"3"},
+		},
+		{
+			name:      "should with required clauses is optional",
+			query:     query.Bool{Must: []query.Query{match("coffee")}, Should: []query.Query{term("sender", "reza")}},
+			want:      []string{"1", "2"},
+			dataReads: []string{"1", "2"},
+		},
+		{
+			name: "minimum_should_match with required clauses",
+			query: query.Bool{
+				Must:               []query.Query{match("coffee")},
+				Should:             []query.Query{term("sender", "reza"), term("city", "bandung")},
+				MinimumShouldMatch: "1",
+			},
+			want: []string{"1", "2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := newDataReader(executorDocuments())
+			e := &queryExecutor{s: newFakeSearcher(reader), now: time.Now(), documents: make(map[string]map[string]interface{})}
+			docs, err := e.execute(context.Background(), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got := docs.ids()
+			sort.Strings(got)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("execute() = %v, want %v", got, tt.want)
+			}
+			if fullScan := reader.reads["documentIDs"] > 0; fullScan != tt.fullScan {
+				t.Errorf("listed every document = %v, want %v", fullScan, tt.fullScan)
+			}
+			if tt.dataReads != nil {
+				dataReads := []string{}
+				for docID := range executorDocuments() {
+					if reader.reads["data:"+docID] > 0 {
+						dataReads = append(dataReads, docID)
+					}
+				}
+				sort.Strings(dataReads)
+				if !reflect.DeepEqual(dataReads, tt.dataReads) {
+					t.Errorf("read the data of %v, want %v", dataReads, tt.dataReads)
+				}
+			}
+		})
+	}
+}
+
+func TestQueryExecutorShouldScores(t *testing.T) {
+	e := &queryExecutor{
+		s:         newFakeSearcher(newDataReader(executorDocuments())),
+		now:       time.Now(),
+		documents: make(map[string]map[string]interface{}),
+	}
+	docs, err := e.execute(context.Background(), query.Bool{
+		Must:   []query.Query{query.Match{Field: query.AllFields, Query: "coffee"}},
+		Filter: []query.Query{query.Term{Field: "city", Value: "jakarta", Boost: 5}},
+		Should: []query.Query{query.Term{Field: "sender", Value: "reza", Boost: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	match, err := e.execute(context.Background(), query.Match{Field: query.AllFields, Query: "coffee"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The filter does not score, the matching should clause adds its boost.
+	if got, want := docs["1"].score, match["1"].score+2; got != want {
+		t.Errorf("score of 1 = %v, want %v", got, want)
+	}
+}
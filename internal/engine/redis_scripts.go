@@ -0,0 +1,160 @@
+package engine
+
+import "github.com/go-redis/redis/v8"
+
+// The Redis engine mutates postings and counters only through these scripts,
+// so several instances can index into the same Redis without losing updates.
+// Keys are derived from the prefix inside the scripts, which ties them to a
+// single (non-cluster) Redis node.
+
+// removeDocumentLua reverts the tracked version of a document. It is shared
+// by the store, delete and expiry scripts.
+const removeDocumentLua = `
+local function remove_document(prefix, doc_id)
+	local tracker_key = prefix .. 'docTracker:' .. doc_id
+	local raw = redis.call('GET', tracker_key)
+	if not raw then
+		redis.call('ZREM', prefix .. 'expiry', doc_id)
+		return false
+	end
+
+	local tracker = cjson.decode(raw)
+	if type(tracker.tokens) == 'table' then
+		for token, _ in pairs(tracker.tokens) do
+			redis.call('HDEL', prefix .. 'index:' .. token, doc_id)
+			redis.call('HDEL', prefix .. 'fieldIndex:' .. token, doc_id)
+			local term_doc_count_key = prefix .. 'termDocCount:' .. token
+			if redis.call('DECR', term_doc_count_key) <= 0 then
+				redis.call('DEL', term_doc_count_key)
+			end
+		end
+	end
+
+	if redis.call('DECRBY', prefix .. 'tokenLen', tracker.length) < 0 then
+		redis.call('SET', prefix .. 'tokenLen', 0)
+	end
+	if redis.call('DECR', prefix .. 'docCount') < 0 then
+		redis.call('SET', prefix .. 'docCount', 0)
+	end
+	if type(tracker.field_lengths) == 'table' then
+		for field, length in pairs(tracker.field_lengths) do
+			if redis.call('HINCRBY', prefix .. 'fieldTokenLen', field, -length) <= 0 then
+				redis.call('HDEL', prefix .. 'fieldTokenLen', field)
+			end
+		end
+	end
+
+	redis.call('DEL', tracker_key, prefix .. 'docTokensLen:' .. doc_id, prefix .. 'docFieldsLen:' .. doc_id,
+		prefix .. 'data:' .. doc_id, prefix .. 'vector:' .. doc_id)
+	redis.call('ZREM', prefix .. 'expiry', doc_id)
+	return tracker
+end
+`
+
+// storeDocumentScript replaces a document atomically. The shared keys live
+// ttl seconds from the last write, the keys of the document live doc ttl.
+// ARGV: prefix, docID, ttl seconds, tracker JSON, data JSON (may be empty),
+// per-token field frequencies JSON, vector JSON (may be empty), doc ttl
+// seconds.
+var storeDocumentScript = redis.NewScript(removeDocumentLua + `
+local prefix, doc_id, ttl, doc_ttl = ARGV[1], ARGV[2], tonumber(ARGV[3]), tonumber(ARGV[8])
+local tracker = cjson.decode(ARGV[4])
+local field_frequency = cjson.decode(ARGV[6])
+
+remove_document(prefix, doc_id)
+
+for token, freq in pairs(tracker.tokens) do
+	local index_key = prefix .. 'index:' .. token
+	redis.call('HSET', index_key, doc_id, freq)
+	redis.call('EXPIRE', index_key, ttl)
+
+	local term_doc_count_key = prefix .. 'termDocCount:' .. token
+	redis.call('INCR', term_doc_count_key)
+	redis.call('EXPIRE', term_doc_count_key, ttl)
+
+	if field_frequency[token] then
+		local field_index_key = prefix .. 'fieldIndex:' .. token
+		redis.call('HSET', field_index_key, doc_id, cjson.encode(field_frequency[token]))
+		redis.call('EXPIRE', field_index_key, ttl)
+	end
+end
+
+if type(tracker.field_lengths) == 'table' then
+	for field, length in pairs(tracker.field_lengths) do
+		redis.call('HINCRBY', prefix .. 'fieldTokenLen', field, length)
+	end
+	redis.call('EXPIRE', prefix .. 'fieldTokenLen', ttl)
+	redis.call('SET', prefix .. 'docFieldsLen:' .. doc_id, cjson.encode(tracker.field_lengths), 'EX', doc_ttl)
+end
+
+redis.call('SET', prefix .. 'docTokensLen:' .. doc_id, tracker.length, 'EX', doc_ttl)
+redis.call('INCRBY', prefix .. 'tokenLen', tracker.length)
+redis.call('EXPIRE', prefix .. 'tokenLen', ttl)
+redis.call('INCR', prefix .. 'docCount')
+redis.call('EXPIRE', prefix .. 'docCount', ttl)
+
+if ARGV[5] ~= '' then
+	redis.call('SET', prefix .. 'data:' .. doc_id, ARGV[5], 'EX', doc_ttl)
+end
+if ARGV[7] ~= '' then
+	redis.call('SET', prefix .. 'vector:' .. doc_id, ARGV[7], 'EX', doc_ttl)
+end
+
+redis.call('SET', prefix .. 'docTracker:' .. doc_id, ARGV[4])
+redis.call('ZADD', prefix .. 'expiry', tracker.expires_at, doc_id)
+return 1
+`)
+
+// removeExpiredScript removes a document only if it is still expired, so a
+// concurrent re-index on another instance is never undone.
+// ARGV: prefix, docID, now (unix seconds).
+var removeExpiredScript = redis.NewScript(removeDocumentLua + `
+local prefix, doc_id, now = ARGV[1], ARGV[2], tonumber(ARGV[3])
+
+local raw = redis.call('GET', prefix .. 'docTracker:' .. doc_id)
+if raw and cjson.decode(raw).expires_at > now then
+	return 0
+end
+
+if remove_document(prefix, doc_id) then
+	return 1
+end
+return 0
+`)
+
+// removeLiveScript removes a document unless it is missing or expired, which
+// is left to removeExpiredScript.
+// ARGV: prefix, docID, now (unix seconds).
+var removeLiveScript = redis.NewScript(removeDocumentLua + `
+local prefix, doc_id, now = ARGV[1], ARGV[2], tonumber(ARGV[3])
+
+local raw = redis.call('GET', prefix .. 'docTracker:' .. doc_id)
+if not raw or cjson.decode(raw).expires_at <= now then
+	return 0
+end
+
+remove_document(prefix, doc_id)
+return 1
+`)
+
+// migratePostingsScript converts a posting list that versions before the hash
+// layout stored as a JSON string into a hash, keeping its TTL. Any other key
+// is left alone.
+// ARGV: posting list key.
+var migratePostingsScript = redis.NewScript(`
+local key = ARGV[1]
+if redis.call('TYPE', key).ok ~= 'string' then
+	return 0
+end
+
+local ttl = redis.call('PTTL', key)
+local postings = cjson.decode(redis.call('GET', key))
+redis.call('DEL', key)
+for doc_id, freq in pairs(postings) do
+	redis.call('HSET', key, doc_id, freq)
+end
+if ttl > 0 and redis.call('EXISTS', key) == 1 then
+	redis.call('PEXPIRE', key, ttl)
+end
+return 1
+`)
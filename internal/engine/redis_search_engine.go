@@ -6,226 +6,588 @@ import (
 	"errors"
 	"github.com/go-redis/redis/v8"
 	"log"
-	"math"
-	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
 	"github.com/ahmadrezamusthafa/search-engine/common/util"
 	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/metrics"
+	"github.com/ahmadrezamusthafa/search-engine/internal/query"
 	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/hnsw"
 )
 
 type RedisSearchEngine struct {
 	mu       sync.RWMutex
 	redisDB  *redis.Client
-	ctx      context.Context
-	tokenLen int
-	docCount int
-	k1       float64
-	b        float64
+	prefix   string
+	settings IndexSettings
+	sim      Similarity
+	// vectors holds the vectors this process has seen: those stored when it
+	// last reloaded, and those it indexed since. Vectors indexed by other
+	// instances show up on the next reload.
+	vectors           *hnsw.Index
+	metrics           metrics.Index
+	writes            writeGate
+	stopJanitor       func()
+	stopVectorRefresh func()
+}
+
+// writeGate counts the running writes so Close can wait for them. The
+// Redis engine writes without holding its lock, every write is a script.
+type writeGate struct {
+	mu      sync.Mutex
+	closed  bool
+	running sync.WaitGroup
+}
+
+func (g *writeGate) enter() error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.closed {
+		return ErrClosed
+	}
+	g.running.Add(1)
+	return nil
+}
+
+func (g *writeGate) leave() {
+	g.running.Done()
+}
+
+// pause waits for the running writes and holds back new ones until the
+// returned resume is called.
+func (g *writeGate) pause() (resume func()) {
+	g.mu.Lock()
+	g.running.Wait()
+	return g.mu.Unlock
+}
+
+func (g *writeGate) close() {
+	g.mu.Lock()
+	g.closed = true
+	g.mu.Unlock()
+
+	g.running.Wait()
 }
 
 const RedisTTL = 2 * time.Hour
 
+// RedisVectorRefreshInterval is how often the kNN graph is rebuilt from the
+// vectors in Redis, which bounds how long the vectors indexed by other
+// instances stay invisible to kNN search.
+const RedisVectorRefreshInterval = 5 * time.Minute
+
 func NewRedisSearchEngine(config config.BM25Config, redisDB *redis.Client) ISearchEngine {
-	ctx := context.Background()
-	tokenLen, docCount := repopulateDataFromRedis(ctx, redisDB)
-	return &RedisSearchEngine{
-		tokenLen: tokenLen,
-		docCount: docCount,
+	return newRedisSearchEngine("", IndexSettings{BM25: config}, redisDB)
+}
+
+// newRedisSearchEngine creates an engine whose keys all start with prefix,
+// giving it its own postings and statistics inside the shared database.
+func newRedisSearchEngine(prefix string, settings IndexSettings, redisDB *redis.Client) *RedisSearchEngine {
+	se := &RedisSearchEngine{
 		redisDB:  redisDB,
-		ctx:      ctx,
-		k1:       config.K1,
-		b:        config.B,
+		prefix:   prefix,
+		settings: settings,
+		sim:      settings.newSimilarity(),
+		metrics:  metrics.ForIndex(indexName(prefix)),
 	}
+	se.migratePostings(context.Background())
+	se.vectors = se.loadVectors(context.Background())
+	se.stopJanitor = startPeriodic(ExpiryJanitorInterval, se.reconcileExpired)
+	se.stopVectorRefresh = startPeriodic(RedisVectorRefreshInterval, se.Reload)
+	return se
 }
 
-func repopulateDataFromRedis(ctx context.Context, redisDB *redis.Client) (int, int) {
-	tokenLen, err := redisDB.Get(ctx, "tokenLen").Int()
+// collectionStats reads the collection statistics. They are shared by every
+// instance indexing into the same Redis, so they are never cached.
+func (se *RedisSearchEngine) collectionStats(ctx context.Context) (int, int, error) {
+	values, err := se.redisDB.MGet(ctx, se.key("tokenLen"), se.key("docCount")).Result()
 	if err != nil {
-		return 0, 0
+		return 0, 0, storageError(err)
 	}
-	docCount, err := redisDB.Get(ctx, "docCount").Int()
-	if err != nil {
-		return 0, 0
-	}
-	return tokenLen, docCount
+	tokenLen, _ := strconv.Atoi(util.InterfaceToString(values[0]))
+	docCount, _ := strconv.Atoi(util.InterfaceToString(values[1]))
+	return tokenLen, docCount, nil
+}
+
+func (se *RedisSearchEngine) key(name string) string {
+	return se.prefix + name
 }
 
-func (se *RedisSearchEngine) StoreDocument(docID string, tokens []string, contents ...structs.Content) {
+func (se *RedisSearchEngine) applySettings(settings IndexSettings) {
 	se.mu.Lock()
 	defer se.mu.Unlock()
 
+	se.settings = settings
+	se.sim = settings.newSimilarity()
+}
+
+func (se *RedisSearchEngine) StoreDocument(ctx context.Context, docID string, tokens []string, contents ...structs.Content) error {
+	return se.storeDocument(ctx, docID, tokens, nil, time.Now().Add(RedisTTL), contents...)
+}
+
+func (se *RedisSearchEngine) StoreVectorDocument(ctx context.Context, docID string, tokens []string, vector []float32, contents ...structs.Content) error {
+	if err := checkVector(se.vectorIndex(), vector); err != nil {
+		return invalidError(err)
+	}
+	return se.storeDocument(ctx, docID, tokens, vector, time.Now().Add(RedisTTL), contents...)
+}
+
+func (se *RedisSearchEngine) ImportDocument(ctx context.Context, doc structs.ExportedDocument) error {
+	expiresAt, err := importExpiry(doc.ExpiresAt, RedisTTL)
+	if err != nil {
+		return err
+	}
+	if doc.Vector != nil {
+		if err = checkVector(se.vectorIndex(), doc.Vector); err != nil {
+			return invalidError(err)
+		}
+	}
+	return se.storeDocument(ctx, doc.ID, doc.Tokens, doc.Vector, expiresAt, doc.Contents()...)
+}
+
+func (se *RedisSearchEngine) storeDocument(ctx context.Context, docID string, tokens []string, vector []float32, expiresAt time.Time, contents ...structs.Content) error {
+	if err := se.writes.enter(); err != nil {
+		return err
+	}
+	defer se.writes.leave()
+
 	tokenFrequency := make(map[string]int)
 	for _, token := range tokens {
 		tokenFrequency[token]++
 	}
+	fieldFrequency, fieldLengths := fieldTokenFrequency(tokenFrequency, contents...)
 
-	se.tokenLen += len(tokens)
-	se.docCount++
+	tracker := docTracker{
+		ExpiresAt:    expiresAt.Unix(),
+		Length:       len(tokens),
+		Tokens:       tokenFrequency,
+		FieldLengths: fieldLengths,
+	}
+	trackerBytes, err := json.Marshal(tracker)
+	if err != nil {
+		return err
+	}
+	fieldFrequencyBytes, err := json.Marshal(fieldFrequency)
+	if err != nil {
+		return err
+	}
 
-	for token, freq := range tokenFrequency {
-		termDocCount, err := se.redisDB.Get(se.ctx, "termDocCount:"+token).Int()
-		if err != nil && !errors.Is(err, redis.Nil) {
-			log.Println(err)
-		}
-		termDocCount++
-		err = se.redisDB.Set(se.ctx, "termDocCount:"+token, termDocCount, RedisTTL).Err()
+	var contentBytes []byte
+	if len(contents) > 0 {
+		contentBytes, err = json.Marshal(storedContent(contents[0]))
 		if err != nil {
-			log.Println(err)
+			return invalidError(err)
 		}
+	}
 
-		docFreqMap := make(map[string]int)
-		res, err := se.redisDB.Get(se.ctx, "index:"+token).Bytes()
-		if err != nil && !errors.Is(err, redis.Nil) {
-			log.Println(err)
+	var vectorBytes []byte
+	if vector != nil {
+		vectorBytes, err = json.Marshal(vector)
+		if err != nil {
+			return err
 		}
+	}
 
-		if res != nil {
-			err = json.Unmarshal(res, &docFreqMap)
-			if err != nil {
-				log.Println(err)
-			}
-		}
+	err = storeDocumentScript.Run(ctx, se.redisDB, nil,
+		se.prefix, docID, int(RedisTTL.Seconds()), trackerBytes, contentBytes, fieldFrequencyBytes, vectorBytes,
+		docTTLSeconds(expiresAt)).Err()
+	if err != nil {
+		return storageError(err)
+	}
+	se.metrics.DocumentsIndexed.Inc()
+
+	if vector == nil {
+		se.vectorIndex().Remove(docID)
+		return nil
+	}
+	return se.vectorIndex().Add(docID, vector)
+}
+
+func (se *RedisSearchEngine) DeleteDocument(ctx context.Context, docID string) error {
+	if err := se.writes.enter(); err != nil {
+		return err
+	}
+	defer se.writes.leave()
 
-		docFreqMap[docID] = freq
-		docFreqMapBytes, err := json.Marshal(docFreqMap)
+	removed, err := removeLiveScript.Run(ctx, se.redisDB, nil, se.prefix, docID, time.Now().Unix()).Int()
+	if err != nil {
+		return storageError(err)
+	}
+	if removed == 0 {
+		return ErrDocumentNotFound
+	}
+	se.vectorIndex().Remove(docID)
+	return nil
+}
+
+// migratePostings converts the posting lists that earlier versions stored as
+// JSON strings into hashes, on which the scripts would fail with WRONGTYPE.
+// Every instance runs it on startup; a converted list is skipped.
+func (se *RedisSearchEngine) migratePostings(ctx context.Context) {
+	migrated := 0
+	iter := se.redisDB.ScanType(ctx, 0, se.key("index:*"), 1000, "string").Iterator()
+	for iter.Next(ctx) {
+		n, err := migratePostingsScript.Run(ctx, se.redisDB, nil, iter.Val()).Int()
 		if err != nil {
-			log.Println(err)
+			log.Printf("Error converting %s to a hash: %v", iter.Val(), err)
+			continue
 		}
+		migrated += n
+	}
+	if err := iter.Err(); err != nil {
+		log.Println(err)
+	}
+	if migrated > 0 {
+		log.Printf("Converted %d posting lists to hashes", migrated)
+	}
+}
 
-		err = se.redisDB.Set(se.ctx, "index:"+token, docFreqMapBytes, RedisTTL).Err()
+// loadVectors builds the kNN graph from the vectors stored in Redis.
+func (se *RedisSearchEngine) loadVectors(ctx context.Context) *hnsw.Index {
+	vectors := hnsw.New(0, 0)
+	vectorPrefix := se.key("vector:")
+
+	iter := se.redisDB.Scan(ctx, 0, vectorPrefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		docID := strings.TrimPrefix(iter.Val(), vectorPrefix)
+		vector, err := se.getVector(ctx, docID)
+		if err == nil && vector != nil {
+			err = vectors.Add(docID, vector)
+		}
 		if err != nil {
 			log.Println(err)
 		}
 	}
+	if err := iter.Err(); err != nil {
+		log.Println(err)
+	}
+	return vectors
+}
 
-	err := se.redisDB.Set(se.ctx, "docTokensLen:"+docID, len(tokens), RedisTTL).Err()
+func (se *RedisSearchEngine) vectorIndex() *hnsw.Index {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	return se.vectors
+}
+
+func (se *RedisSearchEngine) getVector(ctx context.Context, docID string) ([]float32, error) {
+	var vector []float32
+	err := se.getObject(ctx, se.key("vector:"+docID), &vector)
+	return vector, err
+}
+
+func (se *RedisSearchEngine) getTracker(ctx context.Context, docID string) (docTracker, error) {
+	var tracker docTracker
+	err := se.getObject(ctx, se.key("docTracker:"+docID), &tracker)
+	return tracker, err
+}
+
+// reconcileExpired removes documents whose TTL has passed from the postings
+// they were listed in and from the collection statistics. Every instance may
+// run it concurrently, the removal script re-checks expiry atomically.
+func (se *RedisSearchEngine) reconcileExpired() {
+	ctx := context.Background()
+	now := time.Now().Unix()
+
+	expiredDocIDs, err := se.redisDB.ZRangeByScore(ctx, se.key("expiry"), &redis.ZRangeBy{
+		Min: "-inf",
+		Max: strconv.FormatInt(now, 10),
+	}).Result()
 	if err != nil {
 		log.Println(err)
+		return
+	}
+
+	for _, docID := range expiredDocIDs {
+		removed, err := removeExpiredScript.Run(ctx, se.redisDB, nil, se.prefix, docID, now).Int()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			log.Println(err)
+		}
+		if removed == 1 {
+			se.vectorIndex().Remove(docID)
+		}
 	}
-	err = se.redisDB.Set(se.ctx, "tokenLen", se.tokenLen, RedisTTL).Err()
+}
+
+func (se *RedisSearchEngine) Search(ctx context.Context, queries ...string) (structs.SearchResponse, error) {
+	return se.SearchWithOptions(ctx, structs.SearchOptions{}, queries...)
+}
+
+func (se *RedisSearchEngine) SearchWithOptions(ctx context.Context, options structs.SearchOptions, queries ...string) (structs.SearchResponse, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	se.metrics.Searches.Inc()
+	return se.searcher().search(ctx, options, queries)
+}
+
+func (se *RedisSearchEngine) SearchQuery(ctx context.Context, q query.Query, options structs.SearchOptions) (structs.SearchResponse, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	se.metrics.Searches.Inc()
+	return se.searcher().runQuery(ctx, options, q)
+}
+
+func (se *RedisSearchEngine) Count(ctx context.Context, options structs.SearchOptions, queries ...string) (int, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	se.metrics.Searches.Inc()
+	return se.searcher().count(ctx, options, queries)
+}
+
+func (se *RedisSearchEngine) GetDocument(ctx context.Context, docID string) (structs.StoredDocument, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	return se.searcher().getDocument(ctx, docID)
+}
+
+func (se *RedisSearchEngine) DocumentExists(ctx context.Context, docID string) (bool, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	return se.searcher().documentExists(ctx, docID)
+}
+
+func (se *RedisSearchEngine) MoreLikeThis(ctx context.Context, docID string, maxQueryTerms int, options structs.SearchOptions) (structs.SearchResponse, error) {
+	se.mu.RLock()
+	defer se.mu.RUnlock()
+
+	se.metrics.Searches.Inc()
+	return se.searcher().moreLikeThis(ctx, docID, maxQueryTerms, options)
+}
+
+func (se *RedisSearchEngine) searcher() searcher {
+	return searcher{reader: se, settings: se.settings, sim: se.sim, vectors: se.vectors}
+}
+
+func (se *RedisSearchEngine) fieldTokenLengths(ctx context.Context) (map[string]int, error) {
+	res, err := se.redisDB.HGetAll(ctx, se.key("fieldTokenLen")).Result()
 	if err != nil {
-		log.Println(err)
+		return nil, storageError(err)
 	}
-	err = se.redisDB.Set(se.ctx, "docCount", se.docCount, RedisTTL).Err()
+
+	fieldTokenLen := make(map[string]int, len(res))
+	for field, length := range res {
+		fieldTokenLen[field], _ = strconv.Atoi(length)
+	}
+	return fieldTokenLen, nil
+}
+
+func (se *RedisSearchEngine) postings(ctx context.Context, token string) (map[string]int, error) {
+	se.metrics.PostingLookups.Inc()
+	res, err := se.redisDB.HGetAll(ctx, se.key("index:"+token)).Result()
 	if err != nil {
-		log.Println(err)
+		return nil, storageError(err)
 	}
 
-	if len(contents) > 0 {
-		data := map[string]interface{}{
-			"string": contents[0].String,
-			"object": contents[0].Object,
-		}
-		contentBytes, err := json.Marshal(data)
+	docFreqMap := make(map[string]int, len(res))
+	for docID, freq := range res {
+		docFreqMap[docID], err = strconv.Atoi(freq)
 		if err != nil {
 			log.Println(err)
 		}
+	}
+	return docFreqMap, nil
+}
+
+func (se *RedisSearchEngine) fieldPostings(ctx context.Context, token string) (map[string]map[string]int, error) {
+	se.metrics.PostingLookups.Inc()
+	res, err := se.redisDB.HGetAll(ctx, se.key("fieldIndex:"+token)).Result()
+	if err != nil {
+		return nil, storageError(err)
+	}
 
-		err = se.redisDB.Set(se.ctx, "data:"+docID, contentBytes, RedisTTL).Err()
+	fieldFreqMap := make(map[string]map[string]int, len(res))
+	for docID, fieldFreqs := range res {
+		var freqs map[string]int
+		err = json.Unmarshal([]byte(fieldFreqs), &freqs)
 		if err != nil {
 			log.Println(err)
+			continue
 		}
+		fieldFreqMap[docID] = freqs
 	}
+	return fieldFreqMap, nil
 }
 
-func (se *RedisSearchEngine) Search(queries ...string) []structs.SearchResult {
-	se.mu.RLock()
-	defer se.mu.RUnlock()
+func (se *RedisSearchEngine) termDocCount(ctx context.Context, token string) (int, error) {
+	return se.getInt(ctx, se.key("termDocCount:"+token))
+}
+
+func (se *RedisSearchEngine) docLength(ctx context.Context, docID string) (int, error) {
+	return se.getInt(ctx, se.key("docTokensLen:"+docID))
+}
+
+func (se *RedisSearchEngine) docFieldLengths(ctx context.Context, docID string) (map[string]int, error) {
+	var fieldLens map[string]int
+	err := se.getObject(ctx, se.key("docFieldsLen:"+docID), &fieldLens)
+	return fieldLens, err
+}
 
-	if len(queries) == 0 {
+func (se *RedisSearchEngine) documentData(ctx context.Context, docID string) (map[string]interface{}, error) {
+	var value map[string]interface{}
+	err := se.getObject(ctx, se.key("data:"+docID), &value)
+	return value, err
+}
+
+// getInt reads an integer key, 0 when it does not exist.
+func (se *RedisSearchEngine) getInt(ctx context.Context, key string) (int, error) {
+	value, err := se.redisDB.Get(ctx, key).Int()
+	if err != nil && !errors.Is(err, redis.Nil) {
+		return 0, storageError(err)
+	}
+	return value, nil
+}
+
+// getObject decodes a JSON key into target, leaving it unchanged when the
+// key does not exist.
+func (se *RedisSearchEngine) getObject(ctx context.Context, key string, target interface{}) error {
+	res, err := se.redisDB.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
 		return nil
 	}
+	if err != nil {
+		return storageError(err)
+	}
+	return json.Unmarshal(res, target)
+}
 
-	avgDocLen := se.calculateAvgDocLength()
-	docScores := make(map[string]float64)
+// documentIDs reads the live documents from the expiry set.
+func (se *RedisSearchEngine) documentIDs(ctx context.Context) ([]string, error) {
+	docIDs, err := se.redisDB.ZRangeByScore(ctx, se.key("expiry"), &redis.ZRangeBy{
+		Min: "(" + strconv.FormatInt(time.Now().Unix(), 10),
+		Max: "+inf",
+	}).Result()
+	return docIDs, storageError(err)
+}
 
-	for _, query := range queries {
-		docFreqMap := make(map[string]int)
-		res, err := se.redisDB.Get(se.ctx, "index:"+query).Bytes()
-		if err != nil && !errors.Is(err, redis.Nil) {
-			log.Println(err)
-			return nil
-		}
+func (se *RedisSearchEngine) termsWithPrefix(ctx context.Context, prefix string, limit int) ([]string, error) {
+	indexPrefix := se.key("index:")
 
-		if res != nil {
-			err = json.Unmarshal(res, &docFreqMap)
-			if err != nil {
-				log.Println(err)
-			}
-		}
+	var terms []string
+	iter := se.redisDB.Scan(ctx, 0, escapeGlob(indexPrefix+prefix)+"*", 1000).Iterator()
+	for len(terms) < limit && iter.Next(ctx) {
+		terms = append(terms, strings.TrimPrefix(iter.Val(), indexPrefix))
+	}
+	return terms, storageError(iter.Err())
+}
 
-		if len(docFreqMap) == 0 {
-			continue
+// escapeGlob escapes the characters SCAN patterns treat specially.
+func escapeGlob(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		if strings.ContainsRune(`*?[]^\`, r) {
+			b.WriteRune('\\')
 		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
 
-		for docID, tf := range docFreqMap {
-			docLen, err := se.redisDB.Get(se.ctx, "docTokensLen:"+docID).Int()
-			if err != nil && !errors.Is(err, redis.Nil) {
-				log.Println(err)
-			}
+func (se *RedisSearchEngine) ScanDocuments(ctx context.Context, fn func(doc structs.ExportedDocument) bool) error {
+	now := time.Now().Unix()
+	trackerPrefix := se.key("docTracker:")
 
-			termDocCount, err := se.redisDB.Get(se.ctx, "termDocCount:"+query).Int()
-			if err != nil && !errors.Is(err, redis.Nil) {
-				log.Println(err)
-			}
+	iter := se.redisDB.Scan(ctx, 0, trackerPrefix+"*", 1000).Iterator()
+	for iter.Next(ctx) {
+		docID := strings.TrimPrefix(iter.Val(), trackerPrefix)
 
-			bm25Score := se.calculateBM25(tf, termDocCount, docLen, avgDocLen, se.k1, se.b)
-			docScores[docID] += bm25Score
+		tracker, err := se.getTracker(ctx, docID)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if !tracker.isTracked() || tracker.isExpired(now) {
+			continue
 		}
-	}
 
-	results := make([]structs.SearchResult, 0, len(docScores))
-	for docID, score := range docScores {
-		results = append(results, structs.SearchResult{ID: docID, Score: score})
-	}
+		doc := structs.ExportedDocument{
+			ID:        docID,
+			Tokens:    tracker.tokenList(),
+			ExpiresAt: tracker.ExpiresAt,
+		}
 
-	sort.Slice(results, func(i, j int) bool {
-		return results[i].Score > results[j].Score
-	})
+		res, err := se.redisDB.Get(ctx, se.key("data:"+docID)).Bytes()
+		if err != nil && !errors.Is(err, redis.Nil) {
+			log.Println(err)
+		}
 
-	if len(results) > 0 {
-		results = util.GetTopItems(results, 3)
-		for i, result := range results {
-			var value map[string]interface{}
-			res, err := se.redisDB.Get(se.ctx, "data:"+result.ID).Bytes()
-			if err != nil && !errors.Is(err, redis.Nil) {
+		if res != nil {
+			var content structs.Content
+			err = json.Unmarshal(res, &content)
+			if err != nil {
 				log.Println(err)
-				continue
+			} else {
+				doc.Content = &content
 			}
+		}
 
-			if res != nil {
-				err = json.Unmarshal(res, &value)
-				if err != nil {
-					log.Println(err)
-				}
-			}
-			results[i].Data = value
+		doc.Vector, err = se.getVector(ctx, docID)
+		if err != nil {
+			log.Println(err)
 		}
-	}
 
-	return results
+		if !fn(doc) {
+			return nil
+		}
+	}
+	return storageError(iter.Err())
 }
 
 func (se *RedisSearchEngine) GetPersistenceType() string {
 	return "Redis"
 }
 
-func (se *RedisSearchEngine) calculateAvgDocLength() int {
-	if se.docCount == 0 {
-		return 0
-	}
-	return se.tokenLen / se.docCount
+// Reload rebuilds the kNN graph. The Redis engine reads its other
+// statistics on every search. A vector indexed while the graph is rebuilt
+// may be left out until the next reload.
+func (se *RedisSearchEngine) Reload() {
+	vectors := se.loadVectors(context.Background())
+
+	se.mu.Lock()
+	defer se.mu.Unlock()
+
+	se.vectors = vectors
 }
 
-func (se *RedisSearchEngine) calculateBM25(tf, df, docLen, avgDocLen int, k1, b float64) float64 {
-	if df == 0 || avgDocLen == 0 {
-		return 0
+// pause stops the janitor and the vector refresh and holds back writes until
+// resume rebuilds the kNN graph. Searches keep running.
+func (se *RedisSearchEngine) pause() (resume func()) {
+	se.stopJanitor()
+	se.stopVectorRefresh()
+	resumeWrites := se.writes.pause()
+
+	return func() {
+		defer resumeWrites()
+
+		if se.writes.closed {
+			return
+		}
+		se.Reload()
+		se.stopJanitor = startPeriodic(ExpiryJanitorInterval, se.reconcileExpired)
+		se.stopVectorRefresh = startPeriodic(RedisVectorRefreshInterval, se.Reload)
 	}
+}
+
+func (se *RedisSearchEngine) Stats(ctx context.Context) (structs.IndexStats, error) {
+	tokenLen, docCount, err := se.collectionStats(ctx)
+	return structs.IndexStats{DocCount: docCount, TokenCount: tokenLen}, err
+}
 
-	idf := math.Log((float64(se.docCount)-float64(df)+0.5)/(float64(df)+0.5) + 1)
-	tfWeight := (float64(tf) * (k1 + 1)) / (float64(tf) + k1*(1-b+b*float64(docLen)/float64(avgDocLen)))
-	return idf * tfWeight
+// Close stops the janitor and waits for running writes. Everything else is
+// already in Redis.
+func (se *RedisSearchEngine) Close() error {
+	se.stopJanitor()
+	se.stopVectorRefresh()
+	se.writes.close()
+	return nil
 }
@@ -0,0 +1,281 @@
+package engine
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/tokenizer"
+)
+
+const (
+	defaultRescoreWindow = 10
+	defaultExactField    = "total_amount"
+	RescoreModeTotal     = "total"
+)
+
+// RescoreFunc scores one hit in the rescore window. The result carries the
+// original score and the stored document data.
+type RescoreFunc func(queries []string, result structs.SearchResult) float64
+
+var rescoreFuncs = struct {
+	sync.RWMutex
+	m map[string]RescoreFunc
+}{m: make(map[string]RescoreFunc)}
+
+// RegisterRescorer makes fn available to searches as a rescore function
+// under name. It panics if the name is taken or fn is nil, like
+// database/sql.Register.
+func RegisterRescorer(name string, fn RescoreFunc) {
+	rescoreFuncs.Lock()
+	defer rescoreFuncs.Unlock()
+
+	if fn == nil {
+		panic("engine: RegisterRescorer fn is nil")
+	}
+	if _, dup := rescoreFuncs.m[name]; dup {
+		panic("engine: RegisterRescorer called twice for " + name)
+	}
+	rescoreFuncs.m[name] = fn
+}
+
+func lookupRescorer(name string) (RescoreFunc, bool) {
+	rescoreFuncs.RLock()
+	defer rescoreFuncs.RUnlock()
+
+	fn, ok := rescoreFuncs.m[name]
+	return fn, ok
+}
+
+type rescorer interface {
+	rescore(queries []string, result structs.SearchResult) float64
+	description() string
+}
+
+// rescoreStage is the validated form of a structs.Rescore.
+type rescoreStage struct {
+	rescorer      rescorer
+	window        int
+	queryWeight   float64
+	rescoreWeight float64
+	scoreMode     string
+}
+
+func newRescoreStage(cfg *structs.Rescore) (*rescoreStage, error) {
+	stage := &rescoreStage{
+		window:        cfg.WindowSize,
+		queryWeight:   1,
+		rescoreWeight: 1,
+		scoreMode:     cfg.ScoreMode,
+	}
+	if stage.window < 0 {
+		return nil, errors.New("window_size must not be negative")
+	}
+	if stage.window == 0 {
+		stage.window = defaultRescoreWindow
+	}
+	if cfg.QueryWeight != nil {
+		stage.queryWeight = *cfg.QueryWeight
+	}
+	if cfg.RescoreWeight != nil {
+		stage.rescoreWeight = *cfg.RescoreWeight
+	}
+	switch stage.scoreMode {
+	case "":
+		stage.scoreMode = RescoreModeTotal
+	case RescoreModeTotal, ModeMultiply, ModeAvg, ModeMax, ModeMin:
+	default:
+		return nil, fmt.Errorf("unsupported score_mode %q: use total, multiply, avg, max or min", stage.scoreMode)
+	}
+
+	kinds := 0
+	if cfg.Phrase != nil {
+		kinds++
+		if cfg.Phrase.Slop < 0 {
+			return nil, errors.New("phrase slop must not be negative")
+		}
+		stage.rescorer = phraseRescorer{slop: cfg.Phrase.Slop}
+	}
+	if cfg.Exact != nil {
+		kinds++
+		field := cfg.Exact.Field
+		if field == "" {
+			field = defaultExactField
+		}
+		stage.rescorer = exactRescorer{field: field, value: cfg.Exact.Value}
+	}
+	if cfg.Function != "" {
+		kinds++
+		fn, ok := lookupRescorer(cfg.Function)
+		if !ok {
+			return nil, fmt.Errorf("unknown rescore function %q", cfg.Function)
+		}
+		stage.rescorer = functionRescorer{name: cfg.Function, fn: fn}
+	}
+	if kinds != 1 {
+		return nil, errors.New("set exactly one of phrase, exact or function")
+	}
+	return stage, nil
+}
+
+// apply rescores results in place. The results must carry their data.
+func (r *rescoreStage) apply(queries []string, results []structs.SearchResult, explanations map[string]structs.Explanation) {
+	for i, result := range results {
+		rescore := r.rescorer.rescore(queries, result)
+		mode := r.scoreMode
+		if mode == RescoreModeTotal {
+			mode = ModeSum
+		}
+		results[i].Score = combine(mode, []float64{r.queryWeight * result.Score, r.rescoreWeight * rescore})
+
+		if explanation, ok := explanations[result.ID]; ok {
+			explanations[result.ID] = structs.Explanation{
+				Value:       results[i].Score,
+				Description: fmt.Sprintf("rescore, combined by score_mode %s", r.scoreMode),
+				Details: []structs.Explanation{
+					{
+						Value:       r.queryWeight * result.Score,
+						Description: "query_weight * query score",
+						Details:     []structs.Explanation{explainParam(r.queryWeight, "query_weight"), explanation},
+					},
+					{
+						Value:       r.rescoreWeight * rescore,
+						Description: "rescore_weight * rescore",
+						Details:     []structs.Explanation{explainParam(r.rescoreWeight, "rescore_weight"), explainParam(rescore, r.rescorer.description())},
+					},
+				},
+			}
+		}
+	}
+}
+
+// phraseRescorer scores the best field by the share of query terms it
+// contains times how tightly they cluster: 1 when they fit within
+// len(terms) + slop tokens, and proportionally less for wider spans.
+type phraseRescorer struct {
+	slop int
+}
+
+func (p phraseRescorer) rescore(queries []string, result structs.SearchResult) float64 {
+	terms := make(map[string]bool)
+	for _, query := range queries {
+		terms[strings.ToLower(query)] = true
+	}
+	if len(terms) == 0 {
+		return 0
+	}
+
+	data, _ := result.Data.(map[string]interface{})
+	content := structs.Content{Object: documentObject(data)}
+	content.String, _ = data["string"].(string)
+
+	best := 0.0
+	for _, tokens := range tokenizer.TokenizeFields(content) {
+		covered, span := minimumSpan(tokens, terms)
+		if covered == 0 {
+			continue
+		}
+		proximity := 1.0
+		if span > covered+p.slop {
+			proximity = float64(covered+p.slop) / float64(span)
+		}
+		if score := float64(covered) / float64(len(terms)) * proximity; score > best {
+			best = score
+		}
+	}
+	return best
+}
+
+func (p phraseRescorer) description() string {
+	return fmt.Sprintf("phrase proximity with slop %d", p.slop)
+}
+
+// minimumSpan returns how many distinct terms occur in tokens and the length
+// of the shortest run of tokens that contains all of them.
+func minimumSpan(tokens []string, terms map[string]bool) (int, int) {
+	present := make(map[string]bool)
+	for _, token := range tokens {
+		token = strings.ToLower(token)
+		if terms[token] {
+			present[token] = true
+		}
+	}
+	if len(present) == 0 {
+		return 0, 0
+	}
+
+	counts := make(map[string]int)
+	span, start, inWindow := len(tokens), 0, 0
+	for end, token := range tokens {
+		token = strings.ToLower(token)
+		if !present[token] {
+			continue
+		}
+		if counts[token] == 0 {
+			inWindow++
+		}
+		counts[token]++
+
+		for ; inWindow == len(present); start++ {
+			if length := end - start + 1; length < span {
+				span = length
+			}
+			first := strings.ToLower(tokens[start])
+			if !present[first] {
+				continue
+			}
+			counts[first]--
+			if counts[first] == 0 {
+				inWindow--
+			}
+		}
+	}
+	return len(present), span
+}
+
+// exactRescorer scores 1 when an object field equals the configured value,
+// or one of the query terms when no value is configured.
+type exactRescorer struct {
+	field string
+	value interface{}
+}
+
+func (e exactRescorer) rescore(queries []string, result structs.SearchResult) float64 {
+	data, _ := result.Data.(map[string]interface{})
+	fieldValue, ok := documentObject(data)[e.field]
+	if !ok {
+		return 0
+	}
+
+	actual := util.InterfaceToString(fieldValue)
+	candidates := queries
+	if e.value != nil {
+		candidates = []string{util.InterfaceToString(e.value)}
+	}
+	for _, candidate := range candidates {
+		if actual != "" && strings.EqualFold(actual, candidate) {
+			return 1
+		}
+	}
+	return 0
+}
+
+func (e exactRescorer) description() string {
+	return fmt.Sprintf("exact match on %s", e.field)
+}
+
+type functionRescorer struct {
+	name string
+	fn   RescoreFunc
+}
+
+func (f functionRescorer) rescore(queries []string, result structs.SearchResult) float64 {
+	return f.fn(queries, result)
+}
+
+func (f functionRescorer) description() string {
+	return fmt.Sprintf("rescore function %s", f.name)
+}
@@ -0,0 +1,105 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+func TestRescoreStageApply(t *testing.T) {
+	RegisterRescorer("test-sender-length", func(queries []string, result structs.SearchResult) float64 {
+		data := result.Data.(map[string]interface{})
+		return float64(len(documentObject(data)["sender_name"].(string)))
+	})
+
+	data := map[string]interface{}{
+		"string": "Journal no: 3453456 TRANSFER DARI Bpk AHMAD REZA MUSTHAFA",
+		"object": map[string]interface{}{
+			"sender_name":  "ahmad reza musthafa",
+			"remark":       "reza ft232312312 ahmad",
+			"total_amount": 5000150.0,
+		},
+	}
+	weight := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name    string
+		rescore structs.Rescore
+		queries []string
+		want    float64
+	}{
+		{
+			name:    "adjacent phrase",
+			rescore: structs.Rescore{Phrase: &structs.PhraseRescore{}},
+			queries: []string{"ahmad", "reza"},
+			want:    2 + 1,
+		},
+		{
+			name:    "partial phrase spread over the field",
+			rescore: structs.Rescore{Phrase: &structs.PhraseRescore{}},
+			queries: []string{"ahmad", "musthafa", "budi"},
+			want:    2 + 2.0/3*2.0/3,
+		},
+		{
+			name:    "slop allows a gap",
+			rescore: structs.Rescore{Phrase: &structs.PhraseRescore{Slop: 1}},
+			queries: []string{"ahmad", "musthafa"},
+			want:    2 + 1,
+		},
+		{
+			name:    "exact total amount from the query terms",
+			rescore: structs.Rescore{Exact: &structs.ExactRescore{}, QueryWeight: weight(0.5), RescoreWeight: weight(4)},
+			queries: []string{"reza", "5000150"},
+			want:    0.5*2 + 4,
+		},
+		{
+			name:    "exact value mismatch",
+			rescore: structs.Rescore{Exact: &structs.ExactRescore{Field: "total_amount", Value: 500150.0}},
+			queries: []string{"5000150"},
+			want:    2,
+		},
+		{
+			name:    "registered function multiplied",
+			rescore: structs.Rescore{Function: "test-sender-length", ScoreMode: ModeMultiply},
+			queries: []string{"reza"},
+			want:    2 * 19,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stage, err := newRescoreStage(&tt.rescore)
+			if err != nil {
+				t.Fatalf("newRescoreStage() error = %v", err)
+			}
+			results := []structs.SearchResult{{ID: "tu:id:4", Score: 2, Data: data}}
+			explanations := map[string]structs.Explanation{"tu:id:4": {Value: 2}}
+			stage.apply(tt.queries, results, explanations)
+			if got := results[0].Score; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("apply() score = %v, want %v", got, tt.want)
+			}
+			if got := explanations["tu:id:4"].Value; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("apply() explanation = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewRescoreStageInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		rescore structs.Rescore
+	}{
+		{name: "no rescorer"},
+		{name: "two rescorers", rescore: structs.Rescore{Phrase: &structs.PhraseRescore{}, Exact: &structs.ExactRescore{}}},
+		{name: "unknown function", rescore: structs.Rescore{Function: "missing"}},
+		{name: "unknown score mode", rescore: structs.Rescore{Phrase: &structs.PhraseRescore{}, ScoreMode: "first"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := newRescoreStage(&tt.rescore); err == nil {
+				t.Error("newRescoreStage() expected an error")
+			}
+		})
+	}
+}
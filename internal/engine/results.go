@@ -0,0 +1,87 @@
+package engine
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+
+	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/tokenizer"
+)
+
+// sortResults orders the results by the sort keys; hits tying on every key
+// keep their score order. Hits without a value for a field sort after the
+// others in either direction. The results must carry their data.
+func sortResults(results []structs.SearchResult, keys []structs.SortField) {
+	sort.SliceStable(results, func(i, j int) bool {
+		for _, key := range keys {
+			if c := compareSortKey(results[i], results[j], key); c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+}
+
+func compareSortKey(a, b structs.SearchResult, key structs.SortField) int {
+	var c int
+	if key.Field == structs.ScoreField {
+		c = cmp.Compare(a.Score, b.Score)
+	} else {
+		dataA, _ := a.Data.(map[string]interface{})
+		dataB, _ := b.Data.(map[string]interface{})
+		valueA, valueB := documentField(dataA, key.Field), documentField(dataB, key.Field)
+		switch {
+		case valueA == nil && valueB == nil:
+			return 0
+		case valueA == nil:
+			return 1
+		case valueB == nil:
+			return -1
+		}
+		c = compareValues(valueA, valueB)
+	}
+
+	if key.Descending() {
+		return -c
+	}
+	return c
+}
+
+// compareValues compares two field values as numbers or dates when both are,
+// and as strings otherwise.
+func compareValues(a, b interface{}) int {
+	numberA, okA := fieldNumber(map[string]interface{}{"a": a}, "a")
+	numberB, okB := fieldNumber(map[string]interface{}{"b": b}, "b")
+	if okA && okB {
+		return cmp.Compare(numberA, numberB)
+	}
+	return strings.Compare(util.InterfaceToString(a), util.InterfaceToString(b))
+}
+
+// filterSource returns the part of stored document data the filter selects.
+// The data is copied, never modified.
+func filterSource(data map[string]interface{}, filter structs.SourceFilter) interface{} {
+	if filter.Disabled || data == nil {
+		return nil
+	}
+	if len(filter.Includes) == 0 {
+		return data
+	}
+
+	object := documentObject(data)
+	filtered := make(map[string]interface{})
+	filteredObject := make(map[string]interface{})
+	for _, field := range filter.Includes {
+		if value, ok := object[field]; ok {
+			filteredObject[field] = value
+		} else if field == tokenizer.StringField {
+			filtered["string"] = data["string"]
+		}
+	}
+	if len(filteredObject) > 0 {
+		filtered["object"] = filteredObject
+	}
+	return filtered
+}
@@ -0,0 +1,60 @@
+package engine
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+func TestSortResults(t *testing.T) {
+	hit := func(id string, score float64, object map[string]interface{}) structs.SearchResult {
+		return structs.SearchResult{ID: id, Score: score, Data: map[string]interface{}{"object": object}}
+	}
+	results := []structs.SearchResult{
+		hit("1", 1, map[string]interface{}{"bank": "bca", "amount": 100.0}),
+		hit("2", 3, map[string]interface{}{"bank": "bni", "amount": 900.0}),
+		hit("3", 2, map[string]interface{}{"bank": "bca"}),
+		hit("4", 4, map[string]interface{}{"bank": "bca", "amount": "500"}),
+	}
+
+	sortResults(results, []structs.SortField{{Field: "bank"}, {Field: "amount", Order: structs.SortDesc}})
+	var got []string
+	for _, result := range results {
+		got = append(got, result.ID)
+	}
+	// The missing amount sorts last, the string amount as a number.
+	if want := []string{"4", "1", "3", "2"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("sortResults() = %v, want %v", got, want)
+	}
+
+	sortResults(results, []structs.SortField{{Field: structs.ScoreField}})
+	if results[0].ID != "4" || results[3].ID != "1" {
+		t.Errorf("sortResults() by _score starts with %s and ends with %s, want 4 and 1", results[0].ID, results[3].ID)
+	}
+}
+
+func TestFilterSource(t *testing.T) {
+	data := map[string]interface{}{
+		"string": "rent",
+		"object": map[string]interface{}{"bank": "bca", "amount": 100.0},
+	}
+
+	if got := filterSource(data, structs.SourceFilter{Disabled: true}); got != nil {
+		t.Errorf("filterSource() disabled = %v, want nil", got)
+	}
+	if got := filterSource(data, structs.SourceFilter{}); !reflect.DeepEqual(got, data) {
+		t.Errorf("filterSource() = %v, want all data", got)
+	}
+
+	want := map[string]interface{}{
+		"string": "rent",
+		"object": map[string]interface{}{"bank": "bca"},
+	}
+	if got := filterSource(data, structs.SourceFilter{Includes: []string{"bank", "string", "missing"}}); !reflect.DeepEqual(got, want) {
+		t.Errorf("filterSource() = %v, want %v", got, want)
+	}
+	if len(documentObject(data)) != 2 {
+		t.Error("filterSource() modified the stored data")
+	}
+}
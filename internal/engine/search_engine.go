@@ -1,17 +1,67 @@
 package engine
 
 import (
+	"context"
 	"fmt"
 	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/query"
 	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
 	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
 	"github.com/go-redis/redis/v8"
 )
 
+// ISearchEngine is implemented by the Redis and BadgerDB engines. Their
+// errors are *Error values of one of the kinds ErrInvalid, ErrNotFound,
+// ErrUnavailable and ErrTimeout, except for unexpected failures. A search
+// past the deadline of its context fails with ErrTimeout.
 type ISearchEngine interface {
-	StoreDocument(docID string, tokens []string, contents ...structs.Content)
-	Search(queries ...string) []structs.SearchResult
+	StoreDocument(ctx context.Context, docID string, tokens []string, contents ...structs.Content) error
+	// StoreVectorDocument stores a document like StoreDocument together with
+	// its dense vector for kNN search. It fails without storing anything when
+	// the vector is empty or all zeros, or its dimension differs from the
+	// vectors already indexed.
+	StoreVectorDocument(ctx context.Context, docID string, tokens []string, vector []float32, contents ...structs.Content) error
+	// DeleteDocument removes a document with its postings and statistics. It
+	// returns ErrDocumentNotFound when the document does not exist or has
+	// expired.
+	DeleteDocument(ctx context.Context, docID string) error
+	Search(ctx context.Context, queries ...string) (structs.SearchResponse, error)
+	// SearchWithOptions searches for the query terms. With a timeout in the
+	// options it returns the hits found when the timeout expires, flagged
+	// as timed out.
+	SearchWithOptions(ctx context.Context, options structs.SearchOptions, queries ...string) (structs.SearchResponse, error)
+	// SearchQuery runs a query tree of the search DSL. A nil query runs only
+	// the kNN search of the options.
+	SearchQuery(ctx context.Context, q query.Query, options structs.SearchOptions) (structs.SearchResponse, error)
+	// Count returns the number of hits of a search, ignoring paging.
+	Count(ctx context.Context, options structs.SearchOptions, queries ...string) (int, error)
+	// GetDocument returns a stored document, with nil data when it was
+	// stored without content. It returns ErrDocumentNotFound when the
+	// document does not exist or has expired.
+	GetDocument(ctx context.Context, docID string) (structs.StoredDocument, error)
+	// DocumentExists reports whether a document is stored and has not
+	// expired, without reading its data.
+	DocumentExists(ctx context.Context, docID string) (bool, error)
+	// MoreLikeThis searches for documents similar to a stored one. It
+	// returns ErrDocumentNotFound when the document does not exist.
+	MoreLikeThis(ctx context.Context, docID string, maxQueryTerms int, options structs.SearchOptions) (structs.SearchResponse, error)
+	// ImportDocument stores a document read by ScanDocuments, possibly of
+	// another engine, with the expiry it had there. A document without
+	// expiry gets the default TTL, one that has expired fails with
+	// ErrInvalid.
+	ImportDocument(ctx context.Context, doc structs.ExportedDocument) error
+	// ScanDocuments calls fn for every live document until fn returns false.
+	ScanDocuments(ctx context.Context, fn func(doc structs.ExportedDocument) bool) error
 	GetPersistenceType() string
+	// Stats returns the number of stored documents and of their tokens.
+	Stats(ctx context.Context) (structs.IndexStats, error)
+	// Reload re-reads the collection statistics from storage, e.g. after a
+	// snapshot has been restored underneath the engine.
+	Reload()
+	// Close stops the expiry janitor, waits for running writes and flushes
+	// the statistics kept in memory. Later writes fail with ErrClosed. The
+	// storage stays open, it may be shared with other engines.
+	Close() error
 }
 
 const (
@@ -24,15 +74,20 @@ func NewSearchEngine[T any](
 	cfg *config.Config,
 	db T) (ISearchEngine, error) {
 
+	settings := defaultIndexSettings(cfg)
+	if err := settings.validate(); err != nil {
+		return nil, err
+	}
+
 	switch persistenceType {
 	case "redis":
 		if redisClient, ok := any(db).(*redis.Client); ok {
-			return NewRedisSearchEngine(cfg.BM25, redisClient), nil
+			return newRedisSearchEngine("", settings, redisClient), nil
 		}
 		return nil, fmt.Errorf("invalid type for Redis persistence")
 	case "badger":
 		if badgerDB, ok := any(db).(*badgerdb.BadgerDB); ok {
-			return NewBadgerSearchEngine(cfg.BM25, badgerDB), nil
+			return newBadgerSearchEngine("", settings, badgerDB), nil
 		}
 		return nil, fmt.Errorf("invalid type for BadgerDB persistence")
 	default:
@@ -0,0 +1,43 @@
+package engine
+
+import (
+	"context"
+	"time"
+)
+
+// searchTimer is the timeout of one search, checked before every posting
+// lookup. Once it has expired the lookups stop and the search returns the
+// hits found so far, flagged as timed out. A nil timer never expires.
+type searchTimer struct {
+	deadline time.Time
+	expired  bool
+}
+
+// newSearchTimer starts the timer of a search option timeout such as "50ms",
+// or returns nil without a timeout. The timeout must have been validated.
+func newSearchTimer(timeout string) *searchTimer {
+	if timeout == "" {
+		return nil
+	}
+	d, _ := time.ParseDuration(timeout)
+	return &searchTimer{deadline: time.Now().Add(d)}
+}
+
+// check fails when the request was cancelled or its deadline has passed,
+// and otherwise reports whether the search timeout has expired.
+func (t *searchTimer) check(ctx context.Context) (bool, error) {
+	if err := ctx.Err(); err != nil {
+		return false, storageError(err)
+	}
+	if t == nil {
+		return false, nil
+	}
+	if !t.expired && !time.Now().Before(t.deadline) {
+		t.expired = true
+	}
+	return t.expired, nil
+}
+
+func (t *searchTimer) timedOut() bool {
+	return t != nil && t.expired
+}
@@ -0,0 +1,42 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSearchTimerCheck(t *testing.T) {
+	ctx := context.Background()
+
+	var timer *searchTimer
+	if expired, err := timer.check(ctx); expired || err != nil {
+		t.Errorf("nil timer check() = %v, %v, want false, nil", expired, err)
+	}
+
+	timer = newSearchTimer("1h")
+	if expired, err := timer.check(ctx); expired || err != nil {
+		t.Errorf("running timer check() = %v, %v, want false, nil", expired, err)
+	}
+
+	timer = &searchTimer{deadline: time.Now().Add(-time.Millisecond)}
+	if expired, err := timer.check(ctx); !expired || err != nil {
+		t.Errorf("expired timer check() = %v, %v, want true, nil", expired, err)
+	}
+	if !timer.timedOut() {
+		t.Error("expired timer timedOut() = false")
+	}
+
+	deadlineCtx, cancel := context.WithDeadline(ctx, time.Now().Add(-time.Millisecond))
+	defer cancel()
+	if _, err := newSearchTimer("1h").check(deadlineCtx); !errors.Is(err, ErrTimeout) {
+		t.Errorf("check() past the request deadline = %v, want ErrTimeout", err)
+	}
+
+	cancelledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	if _, err := timer.check(cancelledCtx); !errors.Is(err, context.Canceled) {
+		t.Errorf("check() of a cancelled request = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,616 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"github.com/ahmadrezamusthafa/search-engine/internal/query"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/hnsw"
+)
+
+const defaultSearchSize = 3
+
+// indexReader is the storage access a search needs. Both engines implement
+// it, so scoring is written once for every backend. Storage failures are
+// returned as storageError.
+type indexReader interface {
+	collectionStats(ctx context.Context) (tokenLen, docCount int, err error)
+	fieldTokenLengths(ctx context.Context) (map[string]int, error)
+	postings(ctx context.Context, token string) (map[string]int, error)
+	fieldPostings(ctx context.Context, token string) (map[string]map[string]int, error)
+	termDocCount(ctx context.Context, token string) (int, error)
+	docLength(ctx context.Context, docID string) (int, error)
+	docFieldLengths(ctx context.Context, docID string) (map[string]int, error)
+	// documentData returns nil without error when the document does not
+	// exist.
+	documentData(ctx context.Context, docID string) (map[string]interface{}, error)
+	// getTracker returns the tracker of docID, or an untracked one when the
+	// document was never stored.
+	getTracker(ctx context.Context, docID string) (docTracker, error)
+	// documentIDs lists the live documents, for queries that cannot use
+	// the postings.
+	documentIDs(ctx context.Context) ([]string, error)
+	// termsWithPrefix lists up to limit indexed terms starting with prefix.
+	termsWithPrefix(ctx context.Context, prefix string, limit int) ([]string, error)
+}
+
+// ValidateSearchOptions reports options a search would reject. The error is
+// of kind ErrInvalid.
+func ValidateSearchOptions(options structs.SearchOptions) error {
+	return invalidError(validateSearchOptions(options))
+}
+
+func validateSearchOptions(options structs.SearchOptions) error {
+	if options.FunctionScore != nil {
+		if _, err := newFunctionScorer(options.FunctionScore, time.Now()); err != nil {
+			return fmt.Errorf("invalid function_score: %w", err)
+		}
+	}
+	if options.From < 0 || options.Size < 0 {
+		return errors.New("invalid from or size: must not be negative")
+	}
+	if options.MinScore < 0 {
+		return errors.New("invalid min_score: must not be negative")
+	}
+	if _, err := minimumShouldMatch(options.MinimumShouldMatch, 1); err != nil {
+		return err
+	}
+	if options.Knn != nil {
+		if err := validateKnn(options.Knn); err != nil {
+			return err
+		}
+		// kNN hits have no keyword score to adjust, and fused hits are
+		// ranks rather than scores.
+		if options.MinScore > 0 || options.FunctionScore != nil {
+			return errors.New("invalid knn: cannot be combined with min_score or function_score")
+		}
+	}
+	if options.Rescore != nil {
+		if _, err := newRescoreStage(options.Rescore); err != nil {
+			return fmt.Errorf("invalid rescore: %w", err)
+		}
+	}
+	for _, key := range options.Sort {
+		if err := key.Validate(); err != nil {
+			return fmt.Errorf("invalid sort: %w", err)
+		}
+		if options.Rescore != nil && key.Field != structs.ScoreField {
+			return errors.New("invalid sort: rescore cannot be combined with sorting by fields")
+		}
+	}
+	if options.Timeout != "" {
+		if timeout, err := time.ParseDuration(options.Timeout); err != nil || timeout <= 0 {
+			return fmt.Errorf("invalid timeout %q: use a positive duration such as 50ms", options.Timeout)
+		}
+	}
+	return nil
+}
+
+type searcher struct {
+	reader   indexReader
+	settings IndexSettings
+	sim      Similarity
+	// vectors is the kNN index, or nil when the engine has none.
+	vectors *hnsw.Index
+	// timer is the timeout of the running search, nil without one.
+	timer *searchTimer
+}
+
+// searchQuery is what a search runs: the query terms, optional per-term
+// boosts and a document to leave out of the results.
+type searchQuery struct {
+	terms   []string
+	boosts  map[string]float64
+	exclude string
+}
+
+func (q searchQuery) boost(term string) float64 {
+	if boost, ok := q.boosts[term]; ok {
+		return boost
+	}
+	return 1
+}
+
+func (s searcher) search(ctx context.Context, options structs.SearchOptions, queries []string) (structs.SearchResponse, error) {
+	return s.run(ctx, options, searchQuery{terms: queries})
+}
+
+func (s searcher) run(ctx context.Context, options structs.SearchOptions, query searchQuery) (structs.SearchResponse, error) {
+	s.timer = newSearchTimer(options.Timeout)
+
+	var match matchPhase
+	if len(query.terms) > 0 {
+		match = func(ctx context.Context, documents map[string]map[string]interface{}, explanations map[string]structs.Explanation) ([]structs.SearchResult, error) {
+			return s.matchTerms(ctx, options, query, explanations)
+		}
+	}
+
+	return s.response(s.execute(ctx, options, query.terms, query.exclude, match))
+}
+
+// runQuery executes a query tree of the search DSL. Without a query only the
+// kNN search of the options runs.
+func (s searcher) runQuery(ctx context.Context, options structs.SearchOptions, q query.Query) (structs.SearchResponse, error) {
+	s.timer = newSearchTimer(options.Timeout)
+
+	var match matchPhase
+	if q != nil {
+		match = func(ctx context.Context, documents map[string]map[string]interface{}, explanations map[string]structs.Explanation) ([]structs.SearchResult, error) {
+			executor := &queryExecutor{s: s, explain: options.Explain, now: time.Now(), documents: documents}
+			docs, err := executor.execute(ctx, q)
+			if err != nil {
+				return nil, err
+			}
+
+			results := make([]structs.SearchResult, 0, len(docs))
+			for docID, doc := range docs {
+				results = append(results, structs.SearchResult{ID: docID, Score: doc.score})
+				if options.Explain {
+					explanations[docID] = doc.explanation
+				}
+			}
+			return results, nil
+		}
+	}
+	return s.response(s.execute(ctx, options, queryTerms(q), "", match))
+}
+
+// response wraps the hits of a search, flagging them when the search timed
+// out.
+func (s searcher) response(hits []structs.SearchResult, err error) (structs.SearchResponse, error) {
+	if err != nil {
+		return structs.SearchResponse{}, err
+	}
+	return structs.SearchResponse{Hits: hits, TimedOut: s.timer.timedOut()}, nil
+}
+
+// matchPhase finds and scores the keyword hits of a search, recording their
+// explanations when explaining. The stored data it reads is kept in
+// documents.
+type matchPhase func(ctx context.Context, documents map[string]map[string]interface{}, explanations map[string]structs.Explanation) ([]structs.SearchResult, error)
+
+// execute runs a search: the match phase and the kNN search, then the
+// rescoring, sorting, collapsing and paging of the hits. terms are the query
+// terms the rescorers see.
+func (s searcher) execute(ctx context.Context, options structs.SearchOptions, terms []string, exclude string, match matchPhase) ([]structs.SearchResult, error) {
+	if match == nil && options.Knn == nil {
+		return nil, nil
+	}
+
+	documents := make(map[string]map[string]interface{})
+	rootExplanations := make(map[string]structs.Explanation)
+
+	var results []structs.SearchResult
+	if match != nil {
+		var err error
+		results, err = match(ctx, documents, rootExplanations)
+		if err != nil {
+			return nil, err
+		}
+		results, err = s.adjustScores(ctx, options, results, documents, rootExplanations)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if options.Knn != nil {
+		hits, err := s.knn(ctx, *options.Knn, exclude, documents)
+		if err != nil {
+			return nil, err
+		}
+		if match == nil {
+			results = hits
+			if options.Explain {
+				for _, hit := range hits {
+					rootExplanations[hit.ID] = explainKnn(hit.Score)
+				}
+			}
+		} else {
+			results = fuseReciprocalRank(results, hits, options.Knn.RankConstant, rootExplanations, options.Explain)
+		}
+	}
+
+	if options.Rescore != nil {
+		stage, err := newRescoreStage(options.Rescore)
+		if err != nil {
+			return nil, invalidError(err)
+		}
+		window := util.GetTopItems(results, stage.window)
+		if err = s.loadData(ctx, window, documents); err != nil {
+			return nil, err
+		}
+		stage.apply(terms, window, rootExplanations)
+		sort.SliceStable(window, func(i, j int) bool {
+			return window[i].Score > window[j].Score
+		})
+	}
+
+	if len(options.Sort) > 0 {
+		if err := s.loadData(ctx, results, documents); err != nil {
+			return nil, err
+		}
+		sortResults(results, options.Sort)
+	}
+
+	if options.Collapse != "" {
+		if err := s.loadData(ctx, results, documents); err != nil {
+			return nil, err
+		}
+		results = collapse(results, options.Collapse)
+	}
+
+	size := options.Size
+	if size == 0 {
+		size = defaultSearchSize
+	}
+	results = util.GetTopItems(results[min(options.From, len(results)):], size)
+
+	if len(results) > 0 {
+		if err := s.loadData(ctx, results, documents); err != nil {
+			return nil, err
+		}
+		for i, result := range results {
+			if options.Source != nil {
+				results[i].Data = filterSource(documents[result.ID], *options.Source)
+			}
+			if explanation, ok := rootExplanations[result.ID]; ok {
+				results[i].Explanation = &explanation
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// matchTerms scores the query terms, keeping the documents that match enough
+// of them.
+func (s searcher) matchTerms(ctx context.Context, options structs.SearchOptions, query searchQuery, rootExplanations map[string]structs.Explanation) ([]structs.SearchResult, error) {
+	requiredTerms, err := minimumShouldMatch(options.MinimumShouldMatch, countTerms(query.terms))
+	if err != nil {
+		return nil, err
+	}
+
+	matches := newTermMatches(options.Explain)
+	if weights, isBM25F := newFieldWeights(s.settings, options.Fields); isBM25F {
+		err = s.scoreBM25F(ctx, query, weights, matches)
+	} else {
+		err = s.scoreTerms(ctx, query, matches)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	results := make([]structs.SearchResult, 0, len(matches.scores))
+	for docID, score := range matches.scores {
+		if matches.matchedTerms[docID] < requiredTerms || docID == query.exclude {
+			continue
+		}
+		results = append(results, structs.SearchResult{ID: docID, Score: score})
+	}
+
+	if matches.explanations != nil {
+		for _, result := range results {
+			rootExplanations[result.ID] = structs.Explanation{
+				Value:       result.Score,
+				Description: "sum of:",
+				Details:     matches.explanations[result.ID],
+			}
+		}
+	}
+	return results, nil
+}
+
+// adjustScores applies the function score and score threshold to the keyword
+// hits, returning them sorted by score.
+func (s searcher) adjustScores(ctx context.Context, options structs.SearchOptions, results []structs.SearchResult, documents map[string]map[string]interface{}, rootExplanations map[string]structs.Explanation) ([]structs.SearchResult, error) {
+	if options.FunctionScore != nil {
+		scorer, err := newFunctionScorer(options.FunctionScore, time.Now())
+		if err != nil {
+			return nil, invalidError(err)
+		}
+		if err = s.loadData(ctx, results, documents); err != nil {
+			return nil, err
+		}
+		for i, result := range results {
+			object := documentObject(documents[result.ID])
+			results[i].Score = scorer.apply(result.Score, object)
+			if options.Explain {
+				rootExplanations[result.ID] = scorer.explain(result.Score, rootExplanations[result.ID], object)
+			}
+		}
+	}
+
+	if options.MinScore > 0 {
+		filtered := results[:0]
+		for _, result := range results {
+			if result.Score >= options.MinScore {
+				filtered = append(filtered, result)
+			}
+		}
+		results = filtered
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score != results[j].Score {
+			return results[i].Score > results[j].Score
+		}
+		return results[i].ID < results[j].ID
+	})
+	return results, nil
+}
+
+// loadData sets the stored data of results, reusing documents already read.
+func (s searcher) loadData(ctx context.Context, results []structs.SearchResult, documents map[string]map[string]interface{}) error {
+	for i, result := range results {
+		data, ok := documents[result.ID]
+		if !ok {
+			var err error
+			data, err = s.reader.documentData(ctx, result.ID)
+			if err != nil {
+				return err
+			}
+			documents[result.ID] = data
+		}
+		results[i].Data = data
+	}
+	return nil
+}
+
+// termMatches accumulates what scoring the query terms found per document:
+// the summed score, the number of distinct terms matched and, when
+// explaining, the per-term explanations.
+type termMatches struct {
+	scores       map[string]float64
+	matchedTerms map[string]int
+	explanations map[string][]structs.Explanation
+}
+
+func newTermMatches(explain bool) *termMatches {
+	matches := &termMatches{
+		scores:       make(map[string]float64),
+		matchedTerms: make(map[string]int),
+	}
+	if explain {
+		matches.explanations = make(map[string][]structs.Explanation)
+	}
+	return matches
+}
+
+// add records a term match. newTerm is false for a term repeated in the
+// query, which adds to the score but not to the matched terms.
+func (m *termMatches) add(docID string, score float64, newTerm bool, explain func() structs.Explanation) {
+	m.scores[docID] += score
+	if newTerm {
+		m.matchedTerms[docID]++
+	}
+	if m.explanations != nil {
+		m.explanations[docID] = append(m.explanations[docID], explain())
+	}
+}
+
+// scoreTerms sums the index similarity over the query terms, treating the
+// document as a single bag of tokens.
+func (s searcher) scoreTerms(ctx context.Context, q searchQuery, matches *termMatches) error {
+	tokenLen, docCount, err := s.reader.collectionStats(ctx)
+	if err != nil {
+		return err
+	}
+	avgDocLen := calculateAvgDocLength(tokenLen, docCount)
+	seen := make(map[string]bool)
+
+	for _, query := range q.terms {
+		expired, err := s.timer.check(ctx)
+		if err != nil {
+			return err
+		}
+		if expired {
+			break
+		}
+
+		newTerm := !seen[query]
+		seen[query] = true
+
+		docFreqMap, err := s.reader.postings(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		if len(docFreqMap) == 0 {
+			continue
+		}
+
+		termDocCount, err := s.reader.termDocCount(ctx, query)
+		if err != nil {
+			return err
+		}
+		for docID, tf := range docFreqMap {
+			docLen, err := s.reader.docLength(ctx, docID)
+			if err != nil {
+				return err
+			}
+			stats := TermStats{
+				TF:        tf,
+				DF:        termDocCount,
+				DocLen:    docLen,
+				AvgDocLen: avgDocLen,
+				DocCount:  docCount,
+			}
+			boost := q.boost(query)
+			matches.add(docID, boost*s.sim.Score(stats), newTerm, func() structs.Explanation {
+				return explainTerm(query, docID, boost, s.sim.Explain(stats))
+			})
+		}
+	}
+
+	return nil
+}
+
+// scoreBM25F combines the per-field term frequencies of every query term with
+// the field weights and per-field length normalization.
+func (s searcher) scoreBM25F(ctx context.Context, q searchQuery, weights fieldWeights, matches *termMatches) error {
+	_, docCount, err := s.reader.collectionStats(ctx)
+	if err != nil {
+		return err
+	}
+	fieldTokenLens, err := s.reader.fieldTokenLengths(ctx)
+	if err != nil {
+		return err
+	}
+	avgFieldLens := make(map[string]float64)
+	if docCount > 0 {
+		for field, tokenLen := range fieldTokenLens {
+			avgFieldLens[field] = float64(tokenLen) / float64(docCount)
+		}
+	}
+
+	bm25f := BM25FSimilarity{K1: s.settings.BM25.K1}
+	docFieldLens := make(map[string]map[string]int)
+	seen := make(map[string]bool)
+
+	for _, query := range q.terms {
+		expired, err := s.timer.check(ctx)
+		if err != nil {
+			return err
+		}
+		if expired {
+			break
+		}
+
+		newTerm := !seen[query]
+		seen[query] = true
+
+		fieldFreqMap, err := s.reader.fieldPostings(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		if len(fieldFreqMap) == 0 {
+			continue
+		}
+
+		termDocCount, err := s.reader.termDocCount(ctx, query)
+		if err != nil {
+			return err
+		}
+		for docID, fieldFreqs := range fieldFreqMap {
+			fieldLens, ok := docFieldLens[docID]
+			if !ok {
+				fieldLens, err = s.reader.docFieldLengths(ctx, docID)
+				if err != nil {
+					return err
+				}
+				docFieldLens[docID] = fieldLens
+			}
+
+			fields := make([]FieldTermStats, 0, len(fieldFreqs))
+			for field, tf := range fieldFreqs {
+				fieldConfig, ok := weights.get(field)
+				if !ok {
+					continue
+				}
+				fields = append(fields, FieldTermStats{
+					Field:  field,
+					TF:     tf,
+					Len:    fieldLens[field],
+					AvgLen: avgFieldLens[field],
+					Weight: fieldConfig.Weight,
+					B:      fieldConfig.B,
+				})
+			}
+
+			if score := bm25f.Score(termDocCount, docCount, fields); score > 0 {
+				boost := q.boost(query)
+				matches.add(docID, boost*score, newTerm, func() structs.Explanation {
+					return explainTerm(query, docID, boost, bm25f.Explain(termDocCount, docCount, fields))
+				})
+			}
+		}
+	}
+
+	return nil
+}
+
+func explainTerm(term, docID string, boost float64, explanation structs.Explanation) structs.Explanation {
+	details := []structs.Explanation{explanation}
+	if boost != 1 {
+		details = append(details, explainParam(boost, "boost"))
+	}
+	return structs.Explanation{
+		Value:       boost * explanation.Value,
+		Description: fmt.Sprintf("weight(%s in %s)", term, docID),
+		Details:     details,
+	}
+}
+
+// collapse keeps the best hit of every group of results sharing a value of
+// the object field, in score order, and counts the group on it. Hits without
+// a value are not grouped. The results must carry their data.
+func collapse(results []structs.SearchResult, field string) []structs.SearchResult {
+	groups := make(map[string]int)
+	collapsed := results[:0]
+	for _, result := range results {
+		data, _ := result.Data.(map[string]interface{})
+		value := documentObject(data)[field]
+		key := util.InterfaceToString(value)
+		if key == "" {
+			collapsed = append(collapsed, result)
+			continue
+		}
+
+		if i, ok := groups[key]; ok {
+			collapsed[i].InnerHits.Total++
+			continue
+		}
+		groups[key] = len(collapsed)
+		result.InnerHits = &structs.InnerHits{Field: field, Value: value, Total: 1}
+		collapsed = append(collapsed, result)
+	}
+	return collapsed
+}
+
+// documentObject returns the object part of stored document data.
+func documentObject(data map[string]interface{}) map[string]interface{} {
+	object, _ := data["object"].(map[string]interface{})
+	return object
+}
+
+// countTerms returns the number of distinct query terms.
+func countTerms(queries []string) int {
+	terms := make(map[string]bool, len(queries))
+	for _, query := range queries {
+		terms[query] = true
+	}
+	return len(terms)
+}
+
+// minimumShouldMatch resolves a minimum_should_match spec against the number
+// of query terms: an absolute count ("3"), a percentage ("75%"), or either
+// negated to count the terms allowed to miss ("-1", "-25%"). Percentages round
+// down, and the result is kept between 1 and terms.
+func minimumShouldMatch(spec string, terms int) (int, error) {
+	if spec == "" {
+		return 1, nil
+	}
+
+	value, isPercent := strings.CutSuffix(spec, "%")
+	n, err := strconv.Atoi(value)
+	if err != nil || isPercent && (n < -100 || n > 100) {
+		return 0, invalidError(fmt.Errorf("invalid minimum_should_match %q: use a count such as 3 or -1, or a percentage such as 75%% or -25%%", spec))
+	}
+
+	required := n
+	if isPercent {
+		required = terms * n / 100
+	}
+	if n < 0 {
+		required = terms + required
+	}
+	return max(1, min(required, terms)), nil
+}
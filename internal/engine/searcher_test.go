@@ -0,0 +1,253 @@
+package engine
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/tokenizer"
+)
+
+func TestMinimumShouldMatch(t *testing.T) {
+	tests := []struct {
+		spec    string
+		terms   int
+		want    int
+		wantErr bool
+	}{
+		{spec: "", terms: 4, want: 1},
+		{spec: "3", terms: 4, want: 3},
+		{spec: "6", terms: 4, want: 4},
+		{spec: "-1", terms: 4, want: 3},
+		{spec: "-5", terms: 4, want: 1},
+		{spec: "75%", terms: 4, want: 3},
+		{spec: "60%", terms: 4, want: 2},
+		{spec: "-25%", terms: 4, want: 3},
+		{spec: "-25%", terms: 3, want: 3},
+		{spec: "10%", terms: 4, want: 1},
+		{spec: "150%", terms: 4, wantErr: true},
+		{spec: "most", terms: 4, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			got, err := minimumShouldMatch(tt.spec, tt.terms)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("minimumShouldMatch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("minimumShouldMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCollapse(t *testing.T) {
+	hit := func(id string, sender interface{}) structs.SearchResult {
+		object := map[string]interface{}{}
+		if sender != nil {
+			object["sender_name"] = sender
+		}
+		return structs.SearchResult{ID: id, Data: map[string]interface{}{"object": object}}
+	}
+	results := []structs.SearchResult{
+		hit("1", "reza"),
+		hit("2", "budi"),
+		hit("3", "reza"),
+		hit("4", nil),
+		hit("5", "reza"),
+		hit("6", nil),
+	}
+
+	got := collapse(results, "sender_name")
+	want := []struct {
+		id    string
+		total int
+	}{{"1", 3}, {"2", 1}, {"4", 0}, {"6", 0}}
+	if len(got) != len(want) {
+		t.Fatalf("collapse() returned %d hits, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		total := 0
+		if got[i].InnerHits != nil {
+			total = got[i].InnerHits.Total
+		}
+		if got[i].ID != w.id || total != w.total {
+			t.Errorf("collapse()[%d] = %s with %d inner hits, want %s with %d", i, got[i].ID, total, w.id, w.total)
+		}
+	}
+}
+
+// fakeReader is an in-memory indexReader over the tokens of its documents.
+// Field postings are taken from the stored data; documents without data
+// have no stored content. reads counts the document lists and the stored
+// data read.
+type fakeReader struct {
+	tokens  map[string][]string
+	data    map[string]map[string]interface{}
+	expired map[string]bool
+	reads   map[string]int
+}
+
+func newFakeReader(tokens map[string][]string, data map[string]map[string]interface{}) fakeReader {
+	return fakeReader{tokens: tokens, data: data, expired: make(map[string]bool), reads: make(map[string]int)}
+}
+
+// newDataReader indexes every field of the stored data of the documents.
+func newDataReader(data map[string]map[string]interface{}) fakeReader {
+	tokens := make(map[string][]string, len(data))
+	for docID, d := range data {
+		tokens[docID] = tokenizer.Tokenize(fakeContent(d))
+	}
+	return newFakeReader(tokens, data)
+}
+
+func fakeContent(data map[string]interface{}) structs.Content {
+	content := structs.Content{Object: documentObject(data)}
+	content.String, _ = data["string"].(string)
+	return content
+}
+
+func newFakeSearcher(reader indexReader) searcher {
+	bm25 := config.BM25Config{K1: 1.2, B: 0.75}
+	return searcher{
+		reader:   reader,
+		settings: IndexSettings{BM25: bm25},
+		sim:      BM25Similarity{K1: bm25.K1, B: bm25.B},
+	}
+}
+
+func (r fakeReader) collectionStats(context.Context) (int, int, error) {
+	tokenLen := 0
+	for _, tokens := range r.tokens {
+		tokenLen += len(tokens)
+	}
+	return tokenLen, len(r.tokens), nil
+}
+
+func (r fakeReader) fieldTokenLengths(ctx context.Context) (map[string]int, error) {
+	fieldLens := make(map[string]int)
+	for docID := range r.data {
+		docFieldLens, _ := r.docFieldLengths(ctx, docID)
+		for field, length := range docFieldLens {
+			fieldLens[field] += length
+		}
+	}
+	return fieldLens, nil
+}
+
+func (r fakeReader) postings(_ context.Context, token string) (map[string]int, error) {
+	postings := make(map[string]int)
+	for docID, tokens := range r.tokens {
+		for _, t := range tokens {
+			if t == token {
+				postings[docID]++
+			}
+		}
+	}
+	return postings, nil
+}
+
+func (r fakeReader) fieldPostings(_ context.Context, token string) (map[string]map[string]int, error) {
+	postings := make(map[string]map[string]int)
+	for docID, data := range r.data {
+		for field, tokens := range tokenizer.TokenizeFields(fakeContent(data)) {
+			for _, t := range tokens {
+				if t != token {
+					continue
+				}
+				if postings[docID] == nil {
+					postings[docID] = make(map[string]int)
+				}
+				postings[docID][field]++
+			}
+		}
+	}
+	return postings, nil
+}
+
+func (r fakeReader) termDocCount(ctx context.Context, token string) (int, error) {
+	postings, err := r.postings(ctx, token)
+	return len(postings), err
+}
+
+func (r fakeReader) docLength(_ context.Context, docID string) (int, error) {
+	return len(r.tokens[docID]), nil
+}
+
+func (r fakeReader) docFieldLengths(_ context.Context, docID string) (map[string]int, error) {
+	data, ok := r.data[docID]
+	if !ok {
+		return nil, nil
+	}
+	fieldLens := make(map[string]int)
+	for field, tokens := range tokenizer.TokenizeFields(fakeContent(data)) {
+		fieldLens[field] = len(tokens)
+	}
+	return fieldLens, nil
+}
+
+func (r fakeReader) documentData(_ context.Context, docID string) (map[string]interface{}, error) {
+	r.reads["data:"+docID]++
+	return r.data[docID], nil
+}
+
+func (r fakeReader) getTracker(_ context.Context, docID string) (docTracker, error) {
+	tokens, ok := r.tokens[docID]
+	if !ok {
+		return docTracker{}, nil
+	}
+	expiresAt := time.Now().Add(time.Hour)
+	if r.expired[docID] {
+		expiresAt = time.Now().Add(-time.Hour)
+	}
+	tracker := docTracker{
+		ExpiresAt: expiresAt.Unix(),
+		Length:    len(tokens),
+		Tokens:    make(map[string]int),
+	}
+	for _, token := range tokens {
+		tracker.Tokens[token]++
+	}
+	return tracker, nil
+}
+
+func (r fakeReader) documentIDs(context.Context) ([]string, error) {
+	r.reads["documentIDs"]++
+	docIDs := make([]string, 0, len(r.tokens))
+	for docID := range r.tokens {
+		docIDs = append(docIDs, docID)
+	}
+	sort.Strings(docIDs)
+	return docIDs, nil
+}
+
+func (r fakeReader) termsWithPrefix(_ context.Context, prefix string, limit int) ([]string, error) {
+	seen := make(map[string]bool)
+	var terms []string
+	for _, tokens := range r.tokens {
+		for _, token := range tokens {
+			if strings.HasPrefix(token, prefix) && !seen[token] {
+				seen[token] = true
+				terms = append(terms, token)
+			}
+		}
+	}
+	sort.Strings(terms)
+	if len(terms) > limit {
+		terms = terms[:limit]
+	}
+	return terms, nil
+}
+
+// hitIDs lists the ids of the hits in order.
+func hitIDs(hits []structs.SearchResult) []string {
+	ids := make([]string, 0, len(hits))
+	for _, hit := range hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
@@ -0,0 +1,363 @@
+package engine
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+const (
+	SimilarityBM25     = "bm25"
+	SimilarityBM25Plus = "bm25plus"
+	SimilarityBM25L    = "bm25l"
+	SimilarityTFIDF    = "tfidf"
+	SimilarityBoolean  = "boolean"
+)
+
+const (
+	defaultBM25PlusDelta = 1.0
+	defaultBM25LDelta    = 0.5
+)
+
+// TermStats holds everything a similarity needs to score one query term
+// against one document.
+type TermStats struct {
+	TF        int
+	DF        int
+	DocLen    int
+	AvgDocLen float64
+	DocCount  int
+}
+
+// Similarity scores how well a single query term matches a document. The
+// document score is the sum over the matched query terms. Explain returns the
+// same score broken down into the inputs it was computed from.
+type Similarity interface {
+	Score(stats TermStats) float64
+	Explain(stats TermStats) structs.Explanation
+}
+
+// NewSimilarity builds the model named in cfg with the given BM25 parameters.
+func NewSimilarity(cfg config.SimilarityConfig, bm25 config.BM25Config) (Similarity, error) {
+	switch cfg.Model {
+	case "", SimilarityBM25:
+		return BM25Similarity{K1: bm25.K1, B: bm25.B}, nil
+	case SimilarityBM25Plus:
+		delta := cfg.Delta
+		if delta == 0 {
+			delta = defaultBM25PlusDelta
+		}
+		return BM25PlusSimilarity{K1: bm25.K1, B: bm25.B, Delta: delta}, nil
+	case SimilarityBM25L:
+		delta := cfg.Delta
+		if delta == 0 {
+			delta = defaultBM25LDelta
+		}
+		return BM25LSimilarity{K1: bm25.K1, B: bm25.B, Delta: delta}, nil
+	case SimilarityTFIDF:
+		return TFIDFSimilarity{}, nil
+	case SimilarityBoolean:
+		return BooleanSimilarity{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported similarity model %q: use bm25, bm25plus, bm25l, tfidf or boolean", cfg.Model)
+	}
+}
+
+// BM25Similarity is classic Okapi BM25.
+type BM25Similarity struct {
+	K1 float64
+	B  float64
+}
+
+func (s BM25Similarity) Score(stats TermStats) float64 {
+	if stats.DF == 0 || stats.AvgDocLen == 0 {
+		return 0
+	}
+	return bm25IDF(stats) * bm25TFWeight(stats, s.K1, s.B)
+}
+
+func (s BM25Similarity) Explain(stats TermStats) structs.Explanation {
+	if stats.DF == 0 || stats.AvgDocLen == 0 {
+		return explainNoMatch(stats)
+	}
+
+	idf := explainBM25IDF(stats)
+	tfWeight := explainBM25TFWeight(stats, s.K1, s.B)
+	return structs.Explanation{
+		Value:       s.Score(stats),
+		Description: "bm25, computed as idf * tf-weight",
+		Details:     []structs.Explanation{idf, tfWeight},
+	}
+}
+
+// BM25PlusSimilarity adds Delta to the term frequency weight so long
+// documents matching a term never score below short ones that miss it.
+type BM25PlusSimilarity struct {
+	K1    float64
+	B     float64
+	Delta float64
+}
+
+func (s BM25PlusSimilarity) Score(stats TermStats) float64 {
+	if stats.DF == 0 || stats.AvgDocLen == 0 {
+		return 0
+	}
+	return bm25IDF(stats) * (bm25TFWeight(stats, s.K1, s.B) + s.Delta)
+}
+
+func (s BM25PlusSimilarity) Explain(stats TermStats) structs.Explanation {
+	if stats.DF == 0 || stats.AvgDocLen == 0 {
+		return explainNoMatch(stats)
+	}
+
+	idf := explainBM25IDF(stats)
+	tfWeight := explainBM25TFWeight(stats, s.K1, s.B)
+	return structs.Explanation{
+		Value:       s.Score(stats),
+		Description: "bm25plus, computed as idf * (tf-weight + delta)",
+		Details:     []structs.Explanation{idf, tfWeight, explainParam(s.Delta, "delta")},
+	}
+}
+
+// BM25LSimilarity shifts the length-normalized term frequency by Delta,
+// which counters BM25's bias against long documents.
+type BM25LSimilarity struct {
+	K1    float64
+	B     float64
+	Delta float64
+}
+
+func (s BM25LSimilarity) Score(stats TermStats) float64 {
+	if stats.DF == 0 || stats.AvgDocLen == 0 {
+		return 0
+	}
+
+	return bm25LIDF(stats) * bm25LTFWeight(stats, s.K1, s.B, s.Delta)
+}
+
+func (s BM25LSimilarity) Explain(stats TermStats) structs.Explanation {
+	if stats.DF == 0 || stats.AvgDocLen == 0 {
+		return explainNoMatch(stats)
+	}
+
+	idf := structs.Explanation{
+		Value:       bm25LIDF(stats),
+		Description: "idf, computed as log((docCount + 1) / (df + 0.5))",
+		Details:     []structs.Explanation{explainParam(float64(stats.DF), "df"), explainParam(float64(stats.DocCount), "docCount")},
+	}
+	tfWeight := structs.Explanation{
+		Value:       bm25LTFWeight(stats, s.K1, s.B, s.Delta),
+		Description: "tf-weight, computed as (k1 + 1) * (ctd + delta) / (k1 + ctd + delta) with ctd = tf / (1 - b + b * docLen / avgDocLen)",
+		Details:     append(explainTFParams(stats, s.K1, s.B), explainParam(s.Delta, "delta")),
+	}
+	return structs.Explanation{
+		Value:       s.Score(stats),
+		Description: "bm25l, computed as idf * tf-weight",
+		Details:     []structs.Explanation{idf, tfWeight},
+	}
+}
+
+// TFIDFSimilarity is the classic vector space model: square-rooted term
+// frequency, squared idf and 1/sqrt(docLen) length normalization.
+type TFIDFSimilarity struct{}
+
+func (s TFIDFSimilarity) Score(stats TermStats) float64 {
+	if stats.DF == 0 {
+		return 0
+	}
+
+	idf := tfidfIDF(stats)
+	norm := 1.0
+	if stats.DocLen > 0 {
+		norm = 1 / math.Sqrt(float64(stats.DocLen))
+	}
+	return math.Sqrt(float64(stats.TF)) * idf * idf * norm
+}
+
+func (s TFIDFSimilarity) Explain(stats TermStats) structs.Explanation {
+	if stats.DF == 0 {
+		return explainNoMatch(stats)
+	}
+
+	return structs.Explanation{
+		Value:       s.Score(stats),
+		Description: "tfidf, computed as sqrt(tf) * idf^2 / sqrt(docLen)",
+		Details: []structs.Explanation{
+			explainParam(float64(stats.TF), "tf"),
+			{
+				Value:       tfidfIDF(stats),
+				Description: "idf, computed as 1 + log((docCount + 1) / (df + 1))",
+				Details:     []structs.Explanation{explainParam(float64(stats.DF), "df"), explainParam(float64(stats.DocCount), "docCount")},
+			},
+			explainParam(float64(stats.DocLen), "docLen"),
+		},
+	}
+}
+
+// BooleanSimilarity scores every matched term as 1, so documents rank by the
+// number of distinct query terms they contain.
+type BooleanSimilarity struct{}
+
+func (s BooleanSimilarity) Score(stats TermStats) float64 {
+	if stats.TF == 0 {
+		return 0
+	}
+	return 1
+}
+
+func (s BooleanSimilarity) Explain(stats TermStats) structs.Explanation {
+	if stats.TF == 0 {
+		return explainNoMatch(stats)
+	}
+	return structs.Explanation{Value: 1, Description: "boolean, 1 for every matched term"}
+}
+
+// FieldTermStats is one field's share of a term match, for BM25F.
+type FieldTermStats struct {
+	Field  string
+	TF     int
+	Len    int
+	AvgLen float64
+	Weight float64
+	B      float64
+}
+
+// BM25FSimilarity combines the length-normalized term frequencies of every
+// field, each multiplied by its weight, before BM25 saturation.
+type BM25FSimilarity struct {
+	K1 float64
+}
+
+func (s BM25FSimilarity) Score(df, docCount int, fields []FieldTermStats) float64 {
+	if df == 0 {
+		return 0
+	}
+
+	tf := 0.0
+	for _, field := range fields {
+		tf += field.weightedTF()
+	}
+	if tf == 0 {
+		return 0
+	}
+
+	idf := bm25IDF(TermStats{DF: df, DocCount: docCount})
+	return idf * (tf * (s.K1 + 1)) / (tf + s.K1)
+}
+
+func (s BM25FSimilarity) Explain(df, docCount int, fields []FieldTermStats) structs.Explanation {
+	stats := TermStats{DF: df, DocCount: docCount}
+	score := s.Score(df, docCount, fields)
+	if score == 0 {
+		return explainNoMatch(stats)
+	}
+
+	tf := structs.Explanation{
+		Description: "tf, computed as sum of weight * tf / (1 - b + b * len / avgLen) over fields",
+	}
+	for _, field := range fields {
+		weightedTF := field.weightedTF()
+		tf.Value += weightedTF
+		tf.Details = append(tf.Details, structs.Explanation{
+			Value:       weightedTF,
+			Description: fmt.Sprintf("field %s", field.Field),
+			Details: []structs.Explanation{
+				explainParam(float64(field.TF), "tf"),
+				explainParam(float64(field.Len), "len"),
+				explainParam(field.AvgLen, "avgLen"),
+				explainParam(field.Weight, "weight"),
+				explainParam(field.B, "b"),
+			},
+		})
+	}
+
+	return structs.Explanation{
+		Value:       score,
+		Description: "bm25f, computed as idf * tf * (k1 + 1) / (tf + k1)",
+		Details: []structs.Explanation{
+			explainBM25IDF(stats),
+			tf,
+			explainParam(s.K1, "k1"),
+		},
+	}
+}
+
+func (f FieldTermStats) weightedTF() float64 {
+	norm := 1.0
+	if f.AvgLen > 0 {
+		norm = 1 - f.B + f.B*float64(f.Len)/f.AvgLen
+	}
+	return f.Weight * float64(f.TF) / norm
+}
+
+func bm25IDF(stats TermStats) float64 {
+	return math.Log((float64(stats.DocCount)-float64(stats.DF)+0.5)/(float64(stats.DF)+0.5) + 1)
+}
+
+func bm25TFWeight(stats TermStats, k1, b float64) float64 {
+	tf := float64(stats.TF)
+	return (tf * (k1 + 1)) / (tf + k1*lengthNorm(stats, b))
+}
+
+func bm25LIDF(stats TermStats) float64 {
+	return math.Log((float64(stats.DocCount) + 1) / (float64(stats.DF) + 0.5))
+}
+
+func bm25LTFWeight(stats TermStats, k1, b, delta float64) float64 {
+	ctd := float64(stats.TF) / lengthNorm(stats, b)
+	return ((k1 + 1) * (ctd + delta)) / (k1 + ctd + delta)
+}
+
+func lengthNorm(stats TermStats, b float64) float64 {
+	return 1 - b + b*float64(stats.DocLen)/stats.AvgDocLen
+}
+
+func tfidfIDF(stats TermStats) float64 {
+	return 1 + math.Log((float64(stats.DocCount)+1)/(float64(stats.DF)+1))
+}
+
+func calculateAvgDocLength(tokenLen, docCount int) float64 {
+	if docCount == 0 {
+		return 0
+	}
+	return float64(tokenLen) / float64(docCount)
+}
+
+func explainParam(value float64, name string) structs.Explanation {
+	return structs.Explanation{Value: value, Description: name}
+}
+
+func explainNoMatch(stats TermStats) structs.Explanation {
+	return structs.Explanation{
+		Description: "no match",
+		Details:     []structs.Explanation{explainParam(float64(stats.TF), "tf"), explainParam(float64(stats.DF), "df")},
+	}
+}
+
+func explainBM25IDF(stats TermStats) structs.Explanation {
+	return structs.Explanation{
+		Value:       bm25IDF(stats),
+		Description: "idf, computed as log(1 + (docCount - df + 0.5) / (df + 0.5))",
+		Details:     []structs.Explanation{explainParam(float64(stats.DF), "df"), explainParam(float64(stats.DocCount), "docCount")},
+	}
+}
+
+func explainBM25TFWeight(stats TermStats, k1, b float64) structs.Explanation {
+	return structs.Explanation{
+		Value:       bm25TFWeight(stats, k1, b),
+		Description: "tf-weight, computed as tf * (k1 + 1) / (tf + k1 * (1 - b + b * docLen / avgDocLen))",
+		Details:     explainTFParams(stats, k1, b),
+	}
+}
+
+func explainTFParams(stats TermStats, k1, b float64) []structs.Explanation {
+	return []structs.Explanation{
+		explainParam(float64(stats.TF), "tf"),
+		explainParam(k1, "k1"),
+		explainParam(b, "b"),
+		explainParam(float64(stats.DocLen), "docLen"),
+		explainParam(stats.AvgDocLen, "avgDocLen"),
+	}
+}
@@ -0,0 +1,128 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+)
+
+func TestSimilarityScore(t *testing.T) {
+	bm25 := config.BM25Config{K1: 1.5, B: 0.5}
+	stats := TermStats{TF: 2, DF: 3, DocLen: 10, AvgDocLen: 8, DocCount: 20}
+
+	tests := []struct {
+		name  string
+		model string
+		want  float64
+	}{
+		{
+			name:  "bm25",
+			model: SimilarityBM25,
+			want:  math.Log((20-3+0.5)/(3+0.5)+1) * (2 * 2.5) / (2 + 1.5*(1-0.5+0.5*10.0/8)),
+		},
+		{
+			name:  "bm25 by default",
+			model: "",
+			want:  math.Log((20-3+0.5)/(3+0.5)+1) * (2 * 2.5) / (2 + 1.5*(1-0.5+0.5*10.0/8)),
+		},
+		{
+			name:  "bm25plus",
+			model: SimilarityBM25Plus,
+			want:  math.Log((20-3+0.5)/(3+0.5)+1) * ((2*2.5)/(2+1.5*(1-0.5+0.5*10.0/8)) + 1),
+		},
+		{
+			name:  "bm25l",
+			model: SimilarityBM25L,
+			want: func() float64 {
+				ctd := 2 / (1 - 0.5 + 0.5*10.0/8)
+				return math.Log(21/3.5) * (2.5 * (ctd + 0.5)) / (1.5 + ctd + 0.5)
+			}(),
+		},
+		{
+			name:  "tfidf",
+			model: SimilarityTFIDF,
+			want:  math.Sqrt(2) * math.Pow(1+math.Log(21.0/4), 2) / math.Sqrt(10),
+		},
+		{
+			name:  "boolean",
+			model: SimilarityBoolean,
+			want:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sim, err := NewSimilarity(config.SimilarityConfig{Model: tt.model}, bm25)
+			if err != nil {
+				t.Fatalf("NewSimilarity() error = %v", err)
+			}
+			if got := sim.Score(stats); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+			if got := sim.Explain(stats).Value; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Explain().Value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewSimilarityUnknownModel(t *testing.T) {
+	_, err := NewSimilarity(config.SimilarityConfig{Model: "dfr"}, config.BM25Config{})
+	if err == nil {
+		t.Error("NewSimilarity() expected an error for an unknown model")
+	}
+}
+
+func TestBM25FSimilarityScore(t *testing.T) {
+	sim := BM25FSimilarity{K1: 1.2}
+	idf := math.Log((10-2+0.5)/(2+0.5) + 1)
+
+	tests := []struct {
+		name   string
+		fields []FieldTermStats
+		want   float64
+	}{
+		{
+			name:   "no matching field",
+			fields: nil,
+			want:   0,
+		},
+		{
+			name: "weighted fields with length normalization",
+			fields: []FieldTermStats{
+				{TF: 1, Len: 4, AvgLen: 2, Weight: 3, B: 0.5},
+				{TF: 2, Len: 2, AvgLen: 4, Weight: 0.5, B: 0},
+			},
+			want: func() float64 {
+				tf := 3*1/(1-0.5+0.5*4.0/2) + 0.5*2
+				return idf * (tf * 2.2) / (tf + 1.2)
+			}(),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sim.Score(2, 10, tt.fields); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Score() = %v, want %v", got, tt.want)
+			}
+			if got := sim.Explain(2, 10, tt.fields).Value; math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("Explain().Value = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCalculateAvgDocLength(t *testing.T) {
+	tests := []struct {
+		tokenLen, docCount int
+		want               float64
+	}{
+		{tokenLen: 0, docCount: 0, want: 0},
+		{tokenLen: 7, docCount: 2, want: 3.5},
+		{tokenLen: 1, docCount: 3, want: 1.0 / 3},
+	}
+	for _, tt := range tests {
+		if got := calculateAvgDocLength(tt.tokenLen, tt.docCount); got != tt.want {
+			t.Errorf("calculateAvgDocLength(%d, %d) = %v, want %v", tt.tokenLen, tt.docCount, got, tt.want)
+		}
+	}
+}
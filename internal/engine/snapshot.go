@@ -0,0 +1,109 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/redisdb"
+	"github.com/go-redis/redis/v8"
+)
+
+type ISnapshotter interface {
+	// Backup streams a snapshot into w. A non-zero since asks for an
+	// incremental backup; the returned version feeds the next one.
+	Backup(w io.Writer, since uint64) (uint64, error)
+	// Restore loads a backup. A full restore first drops everything in the
+	// storage; an incremental one is applied over the previous restore.
+	Restore(r io.Reader, incremental bool) error
+}
+
+// pauser is implemented by the engines that can stop writing while the
+// storage is replaced under them. resume reloads what they keep in memory.
+type pauser interface {
+	pause() (resume func())
+}
+
+// Restore loads a backup into the storage served by searchEngine and
+// indexes. Writes and janitors are paused meanwhile, as Badger must not load
+// alongside other transactions, and the engines reload their statistics and
+// vectors afterwards, even when the restore fails. indexes may be nil.
+func Restore(snapshotter ISnapshotter, r io.Reader, incremental bool, searchEngine ISearchEngine, indexes *IndexRegistry) (err error) {
+	if indexes != nil {
+		resume := indexes.pause()
+		defer func() {
+			if resumeErr := resume(); resumeErr != nil {
+				err = errors.Join(err, storageError(resumeErr))
+			}
+		}()
+	}
+	if p, ok := searchEngine.(pauser); ok {
+		defer p.pause()()
+	}
+
+	if err = snapshotter.Restore(r, incremental); err != nil {
+		return storageError(err)
+	}
+	return nil
+}
+
+func NewSnapshotter[T any](persistenceType string, db T) (ISnapshotter, error) {
+	switch persistenceType {
+	case "redis":
+		if redisClient, ok := any(db).(*redis.Client); ok {
+			return &redisSnapshotter{redisDB: redisClient}, nil
+		}
+		return nil, fmt.Errorf("invalid type for Redis persistence")
+	case "badger":
+		if badgerDB, ok := any(db).(*badgerdb.BadgerDB); ok {
+			return &badgerSnapshotter{badgerDB: badgerDB}, nil
+		}
+		return nil, fmt.Errorf("invalid type for BadgerDB persistence")
+	default:
+		return nil, fmt.Errorf("unsupported persistence type: use redis or badger as search engine persistence")
+	}
+}
+
+type badgerSnapshotter struct {
+	badgerDB *badgerdb.BadgerDB
+}
+
+func (s *badgerSnapshotter) Backup(w io.Writer, since uint64) (uint64, error) {
+	return s.badgerDB.Backup(w, since)
+}
+
+func (s *badgerSnapshotter) Restore(r io.Reader, incremental bool) error {
+	if !incremental {
+		if err := s.badgerDB.DropAll(); err != nil {
+			return err
+		}
+	}
+	return s.badgerDB.Restore(r)
+}
+
+type redisSnapshotter struct {
+	redisDB *redis.Client
+}
+
+func (s *redisSnapshotter) Backup(w io.Writer, since uint64) (uint64, error) {
+	if since > 0 {
+		return 0, fmt.Errorf("incremental backups are not supported by Redis persistence")
+	}
+	_, err := redisdb.Dump(context.Background(), s.redisDB, w)
+	return 0, err
+}
+
+// Restore flushes the selected database for a full restore, since the dump
+// holds all of its keys.
+func (s *redisSnapshotter) Restore(r io.Reader, incremental bool) error {
+	ctx := context.Background()
+	if !incremental {
+		if err := s.redisDB.FlushDB(ctx).Err(); err != nil {
+			return err
+		}
+	}
+	_, err := redisdb.Restore(ctx, s.redisDB, r)
+	return err
+}
@@ -0,0 +1,130 @@
+package engine
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+)
+
+// liveIDs lists the stored documents of se.
+func liveIDs(t *testing.T, se ISearchEngine) []string {
+	t.Helper()
+	ids := []string{}
+	for _, id := range []string{"a", "b", "c", "o1"} {
+		exists, err := se.DocumentExists(context.Background(), id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func TestRestore(t *testing.T) {
+	ctx := context.Background()
+	se := newTestBadgerEngine(t)
+	registry := newTestIndexRegistry(t, &config.Config{BM25: config.BM25Config{K1: 1.2, B: 0.75}}, se.badgerDB)
+	snapshotter, err := NewSnapshotter("badger", se.badgerDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := func(se ISearchEngine, id string) {
+		t.Helper()
+		if err := se.StoreDocument(ctx, id, []string{"alpha", id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store(se, "a")
+	if _, err = registry.PutIndex("orders", registry.DefaultSettings("orders")); err != nil {
+		t.Fatal(err)
+	}
+	orders, _ := registry.GetIndex("orders")
+	store(orders, "o1")
+	var full, incremental bytes.Buffer
+	version, err := snapshotter.Backup(&full, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store(se, "b")
+	if _, err = snapshotter.Backup(&incremental, version); err != nil {
+		t.Fatal(err)
+	}
+	store(se, "c")
+	if err = orders.DeleteDocument(ctx, "o1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = Restore(snapshotter, &full, false, se, registry); err != nil {
+		t.Fatal(err)
+	}
+	if got := liveIDs(t, se); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("documents after the full restore = %v, want [a]", got)
+	}
+	if got := liveIDs(t, orders); !reflect.DeepEqual(got, []string{"o1"}) {
+		t.Errorf("documents of orders after the full restore = %v, want [o1]", got)
+	}
+	if stats, _ := se.Stats(ctx); stats.DocCount != 1 || stats.TokenCount != 2 {
+		t.Errorf("stats after the full restore = %+v, want 1 document of 2 tokens", stats)
+	}
+
+	if err = Restore(snapshotter, &incremental, true, se, registry); err != nil {
+		t.Fatal(err)
+	}
+	if got := liveIDs(t, se); !reflect.DeepEqual(got, []string{"a", "b"}) {
+		t.Errorf("documents after the incremental restore = %v, want [a b]", got)
+	}
+	if postings, _ := se.postings(ctx, "alpha"); len(postings) != 2 {
+		t.Errorf("postings of alpha = %v, want a and b", postings)
+	}
+}
+
+func TestRestoreHoldsWrites(t *testing.T) {
+	ctx := context.Background()
+	se := newTestBadgerEngine(t)
+	snapshotter, err := NewSnapshotter("badger", se.badgerDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backup bytes.Buffer
+	if _, err = snapshotter.Backup(&backup, 0); err != nil {
+		t.Fatal(err)
+	}
+
+	pr, pw := io.Pipe()
+	restored := make(chan error, 1)
+	go func() { restored <- Restore(snapshotter, pr, false, se, nil) }()
+	for se.mu.TryLock() {
+		se.mu.Unlock()
+		time.Sleep(time.Millisecond)
+	}
+
+	stored := make(chan error, 1)
+	go func() { stored <- se.StoreDocument(ctx, "a", []string{"alpha"}) }()
+	select {
+	case <-stored:
+		t.Fatal("a write ran during the restore")
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	pw.Write(backup.Bytes())
+	pw.Close()
+	if err = <-restored; err != nil {
+		t.Fatal(err)
+	}
+	if err = <-stored; err != nil {
+		t.Fatal(err)
+	}
+	if got := liveIDs(t, se); !reflect.DeepEqual(got, []string{"a"}) {
+		t.Errorf("documents after the restore = %v, want the write made after it", got)
+	}
+}
@@ -0,0 +1,147 @@
+package engine
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/hnsw"
+)
+
+const (
+	defaultKnnK          = 10
+	defaultNumCandidates = 100
+	defaultRankConstant  = 60
+)
+
+// checkVector reports why vector cannot be added to vectors.
+func checkVector(vectors *hnsw.Index, vector []float32) error {
+	if len(vector) == 0 {
+		return errors.New("vector must not be empty")
+	}
+	if dims := vectors.Dims(); dims != 0 && len(vector) != dims {
+		return fmt.Errorf("vector has %d dimensions, the index holds %d-dimensional vectors", len(vector), dims)
+	}
+	for _, v := range vector {
+		if v != 0 {
+			return nil
+		}
+	}
+	return hnsw.ErrZeroVector
+}
+
+func validateKnn(knn *structs.Knn) error {
+	if len(knn.Vector) == 0 {
+		return errors.New("invalid knn: vector is required")
+	}
+	if knn.K < 0 || knn.NumCandidates < 0 || knn.RankConstant < 0 {
+		return errors.New("invalid knn: k, num_candidates and rank_constant must not be negative")
+	}
+	return nil
+}
+
+// knn returns the nearest live documents to the query vector, scored by
+// cosine similarity. The graph may still hold documents whose data has
+// expired, so hits without data are dropped.
+func (s searcher) knn(ctx context.Context, knn structs.Knn, exclude string, documents map[string]map[string]interface{}) ([]structs.SearchResult, error) {
+	if s.vectors == nil {
+		return nil, nil
+	}
+
+	k := knn.K
+	if k == 0 {
+		k = defaultKnnK
+	}
+	numCandidates := knn.NumCandidates
+	if numCandidates == 0 {
+		numCandidates = defaultNumCandidates
+	}
+	if exclude != "" {
+		k++
+	}
+
+	neighbours, err := s.vectors.Search(knn.Vector, k, numCandidates)
+	if err != nil {
+		return nil, invalidError(err)
+	}
+
+	hits := make([]structs.SearchResult, 0, len(neighbours))
+	for _, neighbour := range neighbours {
+		if neighbour.ID == exclude {
+			continue
+		}
+		data, err := s.reader.documentData(ctx, neighbour.ID)
+		if err != nil {
+			return nil, err
+		}
+		if data == nil {
+			continue
+		}
+		documents[neighbour.ID] = data
+		hits = append(hits, structs.SearchResult{ID: neighbour.ID, Score: neighbour.Similarity})
+	}
+	if exclude != "" && len(hits) == k {
+		hits = hits[:k-1]
+	}
+	return hits, nil
+}
+
+func explainKnn(similarity float64) structs.Explanation {
+	return structs.Explanation{Value: similarity, Description: "knn, cosine similarity to the query vector"}
+}
+
+// fuseReciprocalRank merges the keyword and kNN rankings: every list adds
+// 1 / (rankConstant + rank) to the documents it contains, ranks counting
+// from 1. The fused hits are sorted by score.
+func fuseReciprocalRank(keyword, knn []structs.SearchResult, rankConstant int, explanations map[string]structs.Explanation, explain bool) []structs.SearchResult {
+	if rankConstant == 0 {
+		rankConstant = defaultRankConstant
+	}
+
+	scores := make(map[string]float64, len(keyword)+len(knn))
+	details := make(map[string][]structs.Explanation)
+	for _, list := range []struct {
+		name string
+		hits []structs.SearchResult
+	}{{"keyword", keyword}, {"knn", knn}} {
+		for i, hit := range list.hits {
+			contribution := 1 / float64(rankConstant+i+1)
+			scores[hit.ID] += contribution
+			if !explain {
+				continue
+			}
+
+			source := explainKnn(hit.Score)
+			if list.name == "keyword" {
+				source = explanations[hit.ID]
+			}
+			details[hit.ID] = append(details[hit.ID], structs.Explanation{
+				Value:       contribution,
+				Description: fmt.Sprintf("%s rank %d", list.name, i+1),
+				Details:     []structs.Explanation{source},
+			})
+		}
+	}
+
+	fused := make([]structs.SearchResult, 0, len(scores))
+	for docID, score := range scores {
+		fused = append(fused, structs.SearchResult{ID: docID, Score: score})
+		if explain {
+			explanations[docID] = structs.Explanation{
+				Value:       score,
+				Description: fmt.Sprintf("reciprocal rank fusion, sum of 1 / (%d + rank)", rankConstant),
+				Details:     details[docID],
+			}
+		}
+	}
+
+	sort.Slice(fused, func(i, j int) bool {
+		if fused[i].Score != fused[j].Score {
+			return fused[i].Score > fused[j].Score
+		}
+		return fused[i].ID < fused[j].ID
+	})
+	return fused
+}
@@ -0,0 +1,68 @@
+package engine
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+)
+
+func TestFuseReciprocalRank(t *testing.T) {
+	keyword := []structs.SearchResult{{ID: "a", Score: 3}, {ID: "b", Score: 2}, {ID: "c", Score: 1}}
+	knn := []structs.SearchResult{{ID: "c", Score: 0.9}, {ID: "d", Score: 0.8}}
+
+	explanations := map[string]structs.Explanation{"a": {Value: 3}, "b": {Value: 2}, "c": {Value: 1}}
+	got := fuseReciprocalRank(keyword, knn, 10, explanations, true)
+
+	want := []struct {
+		id    string
+		score float64
+	}{
+		{"c", 1.0/13 + 1.0/11},
+		{"a", 1.0 / 11},
+		{"b", 1.0 / 12},
+		{"d", 1.0 / 12},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("fuseReciprocalRank() returned %d hits, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].ID != w.id || math.Abs(got[i].Score-w.score) > 1e-12 {
+			t.Errorf("fuseReciprocalRank()[%d] = %s %v, want %s %v", i, got[i].ID, got[i].Score, w.id, w.score)
+		}
+		if math.Abs(explanations[w.id].Value-w.score) > 1e-12 {
+			t.Errorf("explanation of %s = %v, want %v", w.id, explanations[w.id].Value, w.score)
+		}
+	}
+	if details := explanations["c"].Details; len(details) != 2 {
+		t.Errorf("explanation of c has %d details, want one per list", len(details))
+	}
+}
+
+func TestValidateSearchOptionsKnn(t *testing.T) {
+	knn := &structs.Knn{Vector: []float32{1, 0}}
+	tests := []struct {
+		name    string
+		options structs.SearchOptions
+		wantErr bool
+	}{
+		{name: "knn", options: structs.SearchOptions{Knn: knn}},
+		{name: "knn with rescore", options: structs.SearchOptions{Knn: knn, Rescore: &structs.Rescore{Exact: &structs.ExactRescore{}}}},
+		{name: "knn without vector", options: structs.SearchOptions{Knn: &structs.Knn{}}, wantErr: true},
+		{name: "knn with min_score", options: structs.SearchOptions{Knn: knn, MinScore: 1}, wantErr: true},
+		{
+			name: "knn with function_score",
+			options: structs.SearchOptions{Knn: knn, FunctionScore: &structs.FunctionScore{Functions: []structs.ScoreFunction{
+				{FieldValueFactor: &structs.FieldValueFactor{Field: "total_amount"}},
+			}}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := validateSearchOptions(tt.options); (err != nil) != tt.wantErr {
+				t.Errorf("validateSearchOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
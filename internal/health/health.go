@@ -0,0 +1,135 @@
+// Package health checks whether the dependencies of the service can serve
+// requests, for the readiness probe.
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+	"github.com/go-redis/redis/v8"
+)
+
+const DefaultTimeout = 2 * time.Second
+
+const (
+	StatusUp   = "up"
+	StatusDown = "down"
+)
+
+// Check reports whether a dependency is usable. Checks that ignore the
+// context are still cut off at the timeout of the checker.
+type Check func(ctx context.Context) error
+
+// Result is the outcome of the check of one dependency.
+type Result struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker runs the checks of every dependency concurrently, each bounded by
+// the timeout.
+type Checker struct {
+	timeout time.Duration
+	checks  []namedCheck
+}
+
+// NewChecker returns a checker without checks; a zero timeout means
+// DefaultTimeout.
+func NewChecker(timeout time.Duration) *Checker {
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	return &Checker{timeout: timeout}
+}
+
+// Add registers the check of the named dependency.
+func (c *Checker) Add(name string, check Check) {
+	c.checks = append(c.checks, namedCheck{name: name, check: check})
+}
+
+// Check runs every check and reports whether all dependencies are up.
+func (c *Checker) Check(ctx context.Context) (map[string]Result, bool) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]Result, len(c.checks))
+		ready   = true
+	)
+	for _, nc := range c.checks {
+		wg.Add(1)
+		go func(nc namedCheck) {
+			defer wg.Done()
+
+			start := time.Now()
+			err := run(ctx, nc.check)
+			result := Result{Status: StatusUp, DurationMs: time.Since(start).Milliseconds()}
+			if err != nil {
+				result.Status = StatusDown
+				result.Error = err.Error()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[nc.name] = result
+			ready = ready && err == nil
+		}(nc)
+	}
+	wg.Wait()
+	return results, ready
+}
+
+// run waits for the check until ctx is done. A check that hangs is left
+// running in the background.
+func run(ctx context.Context, check Check) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- check(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return fmt.Errorf("no answer within the timeout: %w", ctx.Err())
+	}
+}
+
+// Down lists the dependencies that are down, sorted by name.
+func Down(results map[string]Result) string {
+	var names []string
+	for name, result := range results {
+		if result.Status != StatusUp {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Badger checks that the database is open and commits a write.
+func Badger(db *badgerdb.BadgerDB) Check {
+	return func(context.Context) error {
+		return db.CheckWritable()
+	}
+}
+
+// Redis checks that Redis answers PING.
+func Redis(client *redis.Client) Check {
+	return func(ctx context.Context) error {
+		return client.Ping(ctx).Err()
+	}
+}
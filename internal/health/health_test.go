@@ -0,0 +1,68 @@
+package health
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+)
+
+func TestChecker(t *testing.T) {
+	checker := NewChecker(20 * time.Millisecond)
+	checker.Add("up", func(context.Context) error { return nil })
+	checker.Add("failing", func(context.Context) error { return errors.New("connection refused") })
+	checker.Add("hanging", func(context.Context) error {
+		time.Sleep(time.Second)
+		return nil
+	})
+
+	start := time.Now()
+	results, ready := checker.Check(context.Background())
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Check() took %v, want it cut off at the timeout", elapsed)
+	}
+	if ready {
+		t.Error("Check() ready = true, want false")
+	}
+	for name, want := range map[string]string{"up": StatusUp, "failing": StatusDown, "hanging": StatusDown} {
+		if results[name].Status != want {
+			t.Errorf("%s status = %q, want %q", name, results[name].Status, want)
+		}
+	}
+	if got := Down(results); got != "failing, hanging" {
+		t.Errorf("Down() = %q, want %q", got, "failing, hanging")
+	}
+
+	if _, ready := NewChecker(0).Check(context.Background()); !ready {
+		t.Error("Check() without checks ready = false, want true")
+	}
+}
+
+func TestBadger(t *testing.T) {
+	db := badgerdb.NewBadgerDB(config.BadgerConfig{Path: t.TempDir()})
+	check := Badger(db)
+	if err := check(context.Background()); err != nil {
+		t.Fatalf("check of an open database = %v", err)
+	}
+	if version := db.DB.MaxVersion(); version == 0 {
+		t.Error("the check committed no write")
+	}
+	var backup bytes.Buffer
+	if _, err := db.Backup(&backup, 0); err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(backup.Bytes(), []byte("health:")) {
+		t.Error("the backup holds the probe key")
+	}
+
+	if err := db.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := check(context.Background()); err == nil {
+		t.Error("check of a closed database = nil, want an error")
+	}
+}
@@ -0,0 +1,125 @@
+package metrics
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+	"github.com/go-redis/redis/v8"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// statsTimeout bounds the storage reads of one scrape.
+const statsTimeout = 5 * time.Second
+
+// StatsSource reports the collection statistics of an index.
+type StatsSource interface {
+	Stats(ctx context.Context) (structs.IndexStats, error)
+}
+
+// IndexesStatsSource reports the collection statistics of the named indexes.
+type IndexesStatsSource interface {
+	Stats(ctx context.Context) (map[string]structs.IndexStats, error)
+}
+
+var (
+	documentsDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "documents"),
+		"Stored documents by index.", []string{"index"}, nil)
+	tokensDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "tokens"),
+		"Tokens of the stored documents by index.", []string{"index"}, nil)
+
+	badgerLSMSizeDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "badger", "lsm_size_bytes"),
+		"Size of the BadgerDB LSM tree, refreshed by Badger every minute.", nil, nil)
+	badgerVlogSizeDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "badger", "vlog_size_bytes"),
+		"Size of the BadgerDB value log, refreshed by Badger every minute.", nil, nil)
+
+	redisPoolHitsDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis", "pool_hits_total"),
+		"Times a free connection was found in the Redis pool.", nil, nil)
+	redisPoolMissesDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis", "pool_misses_total"),
+		"Times no free connection was found in the Redis pool.", nil, nil)
+	redisPoolTimeoutsDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis", "pool_timeouts_total"),
+		"Times waiting for a Redis pool connection timed out.", nil, nil)
+	redisPoolStaleDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis", "pool_stale_connections_total"),
+		"Stale connections removed from the Redis pool.", nil, nil)
+	redisPoolTotalDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis", "pool_connections"),
+		"Connections in the Redis pool.", nil, nil)
+	redisPoolIdleDesc = prometheus.NewDesc(prometheus.BuildFQName(namespace, "redis", "pool_idle_connections"),
+		"Idle connections in the Redis pool.", nil, nil)
+)
+
+// Collector reads the collection statistics of every index and the state of
+// the storage when scraped. Nil sources are left out.
+type Collector struct {
+	defaultIndex StatsSource
+	indexes      IndexesStatsSource
+	badgerDB     *badgerdb.BadgerDB
+	redis        *redis.Client
+}
+
+func NewCollector(defaultIndex StatsSource, indexes IndexesStatsSource, badgerDB *badgerdb.BadgerDB, redis *redis.Client) *Collector {
+	return &Collector{
+		defaultIndex: defaultIndex,
+		indexes:      indexes,
+		badgerDB:     badgerDB,
+		redis:        redis,
+	}
+}
+
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	for _, desc := range []*prometheus.Desc{
+		documentsDesc, tokensDesc,
+		badgerLSMSizeDesc, badgerVlogSizeDesc,
+		redisPoolHitsDesc, redisPoolMissesDesc, redisPoolTimeoutsDesc,
+		redisPoolStaleDesc, redisPoolTotalDesc, redisPoolIdleDesc,
+	} {
+		ch <- desc
+	}
+}
+
+// Collect skips statistics it fails to read rather than failing the scrape,
+// so the storage metrics stay visible while the storage is in trouble.
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), statsTimeout)
+	defer cancel()
+
+	stats := make(map[string]structs.IndexStats)
+	if c.indexes != nil {
+		indexStats, err := c.indexes.Stats(ctx)
+		if err != nil {
+			log.Printf("Error reading index statistics: %v", err)
+		}
+		for name, s := range indexStats {
+			stats[name] = s
+		}
+	}
+	if c.defaultIndex != nil {
+		s, err := c.defaultIndex.Stats(ctx)
+		if err != nil {
+			log.Printf("Error reading index statistics: %v", err)
+		} else {
+			stats[DefaultIndex] = s
+		}
+	}
+	for name, s := range stats {
+		ch <- prometheus.MustNewConstMetric(documentsDesc, prometheus.GaugeValue, float64(s.DocCount), name)
+		ch <- prometheus.MustNewConstMetric(tokensDesc, prometheus.GaugeValue, float64(s.TokenCount), name)
+	}
+
+	if c.badgerDB != nil {
+		lsm, vlog := c.badgerDB.DB.Size()
+		ch <- prometheus.MustNewConstMetric(badgerLSMSizeDesc, prometheus.GaugeValue, float64(lsm))
+		ch <- prometheus.MustNewConstMetric(badgerVlogSizeDesc, prometheus.GaugeValue, float64(vlog))
+	}
+
+	if c.redis != nil {
+		pool := c.redis.PoolStats()
+		ch <- prometheus.MustNewConstMetric(redisPoolHitsDesc, prometheus.CounterValue, float64(pool.Hits))
+		ch <- prometheus.MustNewConstMetric(redisPoolMissesDesc, prometheus.CounterValue, float64(pool.Misses))
+		ch <- prometheus.MustNewConstMetric(redisPoolTimeoutsDesc, prometheus.CounterValue, float64(pool.Timeouts))
+		ch <- prometheus.MustNewConstMetric(redisPoolStaleDesc, prometheus.CounterValue, float64(pool.StaleConns))
+		ch <- prometheus.MustNewConstMetric(redisPoolTotalDesc, prometheus.GaugeValue, float64(pool.TotalConns))
+		ch <- prometheus.MustNewConstMetric(redisPoolIdleDesc, prometheus.GaugeValue, float64(pool.IdleConns))
+	}
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type fakeStats struct {
+	stats structs.IndexStats
+	err   error
+}
+
+func (f fakeStats) Stats(context.Context) (structs.IndexStats, error) {
+	return f.stats, f.err
+}
+
+type fakeIndexesStats struct {
+	stats map[string]structs.IndexStats
+	err   error
+}
+
+func (f fakeIndexesStats) Stats(context.Context) (map[string]structs.IndexStats, error) {
+	return f.stats, f.err
+}
+
+// gather scrapes the collector and returns the gauges by metric name and
+// index label.
+func gather(t *testing.T, collector *Collector) map[string]map[string]float64 {
+	t.Helper()
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make(map[string]map[string]float64)
+	for _, family := range families {
+		values[family.GetName()] = make(map[string]float64)
+		for _, metric := range family.GetMetric() {
+			index := ""
+			for _, label := range metric.GetLabel() {
+				if label.GetName() == "index" {
+					index = label.GetValue()
+				}
+			}
+			values[family.GetName()][index] = metric.GetGauge().GetValue()
+		}
+	}
+	return values
+}
+
+func TestCollector(t *testing.T) {
+	tests := []struct {
+		name         string
+		defaultIndex StatsSource
+		indexes      IndexesStatsSource
+		want         map[string]map[string]float64
+	}{
+		{
+			name:         "every index",
+			defaultIndex: fakeStats{stats: structs.IndexStats{DocCount: 3, TokenCount: 12}},
+			indexes: fakeIndexesStats{stats: map[string]structs.IndexStats{
+				"transfers": {DocCount: 2, TokenCount: 5},
+			}},
+			want: map[string]map[string]float64{
+				"searchengine_documents": {DefaultIndex: 3, "transfers": 2},
+				"searchengine_tokens":    {DefaultIndex: 12, "transfers": 5},
+			},
+		},
+		{
+			name:         "failed sources are skipped",
+			defaultIndex: fakeStats{err: errors.New("storage down")},
+			indexes: fakeIndexesStats{
+				stats: map[string]structs.IndexStats{"transfers": {DocCount: 2, TokenCount: 5}},
+				err:   errors.New("index orders: storage down"),
+			},
+			want: map[string]map[string]float64{
+				"searchengine_documents": {"transfers": 2},
+				"searchengine_tokens":    {"transfers": 5},
+			},
+		},
+		{
+			name:         "nil sources",
+			defaultIndex: fakeStats{stats: structs.IndexStats{DocCount: 1, TokenCount: 4}},
+			want: map[string]map[string]float64{
+				"searchengine_documents": {DefaultIndex: 1},
+				"searchengine_tokens":    {DefaultIndex: 4},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := gather(t, NewCollector(tt.defaultIndex, tt.indexes, nil, nil))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("collected %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
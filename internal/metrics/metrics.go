@@ -0,0 +1,79 @@
+// Package metrics exposes the Prometheus metrics of the service. Counters are
+// updated where the work happens; the collection statistics and storage
+// sizes are read by a Collector on every scrape.
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "searchengine"
+
+// DefaultIndex labels the metrics of the default index.
+const DefaultIndex = "_default"
+
+var (
+	httpRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by route, method and status code.",
+	}, []string{"route", "method", "code"})
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "Latency of HTTP requests by route and method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"route", "method"})
+
+	documentsIndexed = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "documents_indexed_total",
+		Help:      "Documents stored by index.",
+	}, []string{"index"})
+	searches = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "searches_total",
+		Help:      "Searches, counts and similar document searches by index.",
+	}, []string{"index"})
+	// Postings are read once per query term. The term is not a label, it
+	// would make the series unbounded.
+	postingLookups = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "posting_lookups_total",
+		Help:      "Posting lists read for query terms by index.",
+	}, []string{"index"})
+)
+
+// Index holds the counters of one index.
+type Index struct {
+	DocumentsIndexed prometheus.Counter
+	Searches         prometheus.Counter
+	PostingLookups   prometheus.Counter
+}
+
+// ForIndex returns the counters of the named index.
+func ForIndex(name string) Index {
+	return Index{
+		DocumentsIndexed: documentsIndexed.WithLabelValues(name),
+		Searches:         searches.WithLabelValues(name),
+		PostingLookups:   postingLookups.WithLabelValues(name),
+	}
+}
+
+// ObserveRequest records a served HTTP request. route is the path template
+// of the matched route, which keeps document ids out of the labels.
+func ObserveRequest(route, method string, status int, duration time.Duration) {
+	httpRequests.WithLabelValues(route, method, strconv.Itoa(status)).Inc()
+	httpRequestDuration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// Handler serves the metrics in the Prometheus text format.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,79 @@
+package migration
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"io"
+	"time"
+)
+
+// Export writes every live document of src to w as JSON lines and returns the
+// number of documents written.
+func Export(ctx context.Context, src engine.ISearchEngine, w io.Writer) (int, error) {
+	encoder := json.NewEncoder(w)
+	count := 0
+
+	var writeErr error
+	err := src.ScanDocuments(ctx, func(doc structs.ExportedDocument) bool {
+		writeErr = encoder.Encode(doc)
+		if writeErr != nil {
+			return false
+		}
+		count++
+		return true
+	})
+	if writeErr != nil {
+		return count, writeErr
+	}
+	return count, err
+}
+
+// Import reads JSON lines produced by Export and stores each document in dst,
+// which rebuilds its postings and statistics in the target backend. Documents
+// keep the expiry they had in the source; those that expired since the export
+// are skipped. It returns the number of documents stored.
+func Import(ctx context.Context, dst engine.ISearchEngine, r io.Reader) (int, error) {
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	count := 0
+
+	for line := 1; ; line++ {
+		var doc structs.ExportedDocument
+		err := decoder.Decode(&doc)
+		if errors.Is(err, io.EOF) {
+			return count, nil
+		}
+		if err != nil {
+			return count, lineError(line, err)
+		}
+		if doc.ID == "" {
+			return count, &engine.Error{Kind: engine.ErrInvalid, Err: fmt.Errorf("document %d: missing id", line)}
+		}
+		if doc.ExpiresAt != 0 && doc.ExpiresAt <= time.Now().Unix() {
+			continue
+		}
+
+		if err = dst.ImportDocument(ctx, doc); err != nil {
+			return count, fmt.Errorf("document %d: %w", line, err)
+		}
+		count++
+	}
+}
+
+// lineError reports a line that failed to decode. Malformed JSON is invalid
+// input; errors reading the stream keep their own kind.
+func lineError(line int, err error) error {
+	err = fmt.Errorf("document %d: %w", line, err)
+	var (
+		syntaxErr *json.SyntaxError
+		typeErr   *json.UnmarshalTypeError
+	)
+	if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return &engine.Error{Kind: engine.ErrInvalid, Err: err}
+	}
+	return err
+}
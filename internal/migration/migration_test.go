@@ -0,0 +1,118 @@
+package migration
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+)
+
+func newTestEngine(t *testing.T) engine.ISearchEngine {
+	t.Helper()
+	db := badgerdb.NewBadgerDB(config.BadgerConfig{Path: t.TempDir()})
+	t.Cleanup(func() { db.Close() })
+	se, err := engine.NewSearchEngine("badger", &config.Config{BM25: config.BM25Config{K1: 1.2, B: 0.75}}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { se.Close() })
+	return se
+}
+
+func scanAll(t *testing.T, se engine.ISearchEngine) []structs.ExportedDocument {
+	t.Helper()
+	var docs []structs.ExportedDocument
+	err := se.ScanDocuments(context.Background(), func(doc structs.ExportedDocument) bool {
+		docs = append(docs, doc)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(docs, func(i, j int) bool { return docs[i].ID < docs[j].ID })
+	return docs
+}
+
+func hitIDs(t *testing.T, se engine.ISearchEngine, fields map[string]float64, terms ...string) []string {
+	t.Helper()
+	response, err := se.SearchWithOptions(context.Background(), structs.SearchOptions{Fields: fields}, terms...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []string
+	for _, hit := range response.Hits {
+		ids = append(ids, hit.ID)
+	}
+	return ids
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	ctx := context.Background()
+	src := newTestEngine(t)
+	err := src.StoreDocument(ctx, "order-1", []string{"alpha", "beta", "jakarta"}, structs.Content{
+		Object:        map[string]interface{}{"name": "alpha beta", "city": "jakarta"},
+		ObjectIndexes: []string{"name"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = src.StoreVectorDocument(ctx, "order-2", []string{"jakarta"}, []float32{1, 0}, structs.Content{
+		String: "jakarta",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = src.StoreDocument(ctx, "order-3", []string{"gamma"}); err != nil {
+		t.Fatal(err)
+	}
+
+	var exported bytes.Buffer
+	count, err := Export(ctx, src, &exported)
+	if err != nil || count != 3 {
+		t.Fatalf("Export() = %d, %v, want 3 documents", count, err)
+	}
+	expired, _ := json.Marshal(structs.ExportedDocument{
+		ID:        "expired",
+		Tokens:    []string{"delta"},
+		ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+	})
+	exported.Write(append(expired, '\n'))
+
+	// A little wait shows that the import keeps the expiry instead of
+	// restarting the TTL.
+	time.Sleep(1100 * time.Millisecond)
+	dst := newTestEngine(t)
+	count, err = Import(ctx, dst, &exported)
+	if err != nil || count != 3 {
+		t.Fatalf("Import() = %d, %v, want 3 documents", count, err)
+	}
+
+	want, got := scanAll(t, src), scanAll(t, dst)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("imported documents = %+v, want %+v", got, want)
+	}
+	if len(got) > 0 && !reflect.DeepEqual(got[0].Content.ObjectIndexes, []string{"name"}) {
+		t.Errorf("object indexes of order-1 = %v, want [name]", got[0].Content.ObjectIndexes)
+	}
+
+	for _, fields := range []map[string]float64{{"name": 1}, {"city": 1}, nil} {
+		want, got := hitIDs(t, src, fields, "jakarta", "alpha"), hitIDs(t, dst, fields, "jakarta", "alpha")
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("hits in fields %v = %v, want %v", fields, got, want)
+		}
+	}
+	if ids := hitIDs(t, dst, map[string]float64{"city": 1}, "jakarta"); len(ids) != 0 {
+		t.Errorf("hits in the unindexed city field = %v, want none", ids)
+	}
+	if ids := hitIDs(t, dst, nil, "delta"); len(ids) != 0 {
+		t.Errorf("hits of the expired document = %v, want none", ids)
+	}
+}
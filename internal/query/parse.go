@@ -0,0 +1,325 @@
+package query
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Parse reads a JSON query such as
+//
+//	{"bool": {"must": [{"match": {"remark": "transfer"}}],
+//	          "filter": {"range": {"total_amount": {"gte": 100}}}}}
+//
+// An empty or null query matches every document.
+func Parse(data []byte) (Query, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return MatchAll{}, nil
+	}
+	return parse(data, "query")
+}
+
+func parse(data json.RawMessage, path string) (Query, error) {
+	var clause map[string]json.RawMessage
+	if err := json.Unmarshal(data, &clause); err != nil {
+		return nil, fmt.Errorf("%s: must be an object", path)
+	}
+	if len(clause) != 1 {
+		return nil, fmt.Errorf("%s: must have exactly one query type, got %d", path, len(clause))
+	}
+
+	for name, body := range clause {
+		path = path + "." + name
+		switch name {
+		case "bool":
+			return parseBool(body, path)
+		case "match":
+			return parseMatch(body, path)
+		case "term":
+			return parseTerm(body, path)
+		case "range":
+			return parseRange(body, path)
+		case "prefix":
+			return parsePrefix(body, path)
+		case "match_all":
+			var matchAll struct {
+				Boost float64 `json:"boost"`
+			}
+			if err := decode(body, &matchAll); err != nil {
+				return nil, fmt.Errorf("%s: %w", path, err)
+			}
+			return MatchAll{Boost: matchAll.Boost}, nil
+		default:
+			return nil, fmt.Errorf("%s: unknown query type, use bool, match, term, range, prefix or match_all", path)
+		}
+	}
+	return nil, nil
+}
+
+func parseBool(data json.RawMessage, path string) (Query, error) {
+	var raw struct {
+		Must               json.RawMessage `json:"must"`
+		Should             json.RawMessage `json:"should"`
+		MustNot            json.RawMessage `json:"must_not"`
+		Filter             json.RawMessage `json:"filter"`
+		MinimumShouldMatch json.RawMessage `json:"minimum_should_match"`
+		Boost              float64         `json:"boost"`
+	}
+	if err := decode(data, &raw); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	q := Bool{Boost: raw.Boost}
+	var err error
+	for _, clauses := range []struct {
+		name   string
+		data   json.RawMessage
+		target *[]Query
+	}{
+		{"must", raw.Must, &q.Must},
+		{"should", raw.Should, &q.Should},
+		{"must_not", raw.MustNot, &q.MustNot},
+		{"filter", raw.Filter, &q.Filter},
+	} {
+		*clauses.target, err = parseClauses(clauses.data, path+"."+clauses.name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	q.MinimumShouldMatch, err = parseMinimumShouldMatch(raw.MinimumShouldMatch)
+	if err != nil {
+		return nil, fmt.Errorf("%s.minimum_should_match: %w", path, err)
+	}
+	return q, nil
+}
+
+// parseClauses reads one clause or an array of clauses.
+func parseClauses(data json.RawMessage, path string) ([]Query, error) {
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || bytes.Equal(data, []byte("null")) {
+		return nil, nil
+	}
+
+	if data[0] != '[' {
+		q, err := parse(data, path)
+		if err != nil {
+			return nil, err
+		}
+		return []Query{q}, nil
+	}
+
+	var items []json.RawMessage
+	if err := json.Unmarshal(data, &items); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	clauses := make([]Query, 0, len(items))
+	for i, item := range items {
+		q, err := parse(item, fmt.Sprintf("%s[%d]", path, i))
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, q)
+	}
+	return clauses, nil
+}
+
+func parseMatch(data json.RawMessage, path string) (Query, error) {
+	field, body, err := fieldClause(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	q := Match{Field: field}
+	if err = json.Unmarshal(body, &q.Query); err == nil {
+		return q, nil
+	}
+
+	var options struct {
+		Query              *string         `json:"query"`
+		Operator           string          `json:"operator"`
+		MinimumShouldMatch json.RawMessage `json:"minimum_should_match"`
+		Boost              float64         `json:"boost"`
+	}
+	if err = decode(body, &options); err != nil {
+		return nil, fmt.Errorf("%s.%s: must be a string or an object with query: %w", path, field, err)
+	}
+	if options.Query == nil {
+		return nil, fmt.Errorf("%s.%s: query is required", path, field)
+	}
+
+	q.Query, q.Boost = *options.Query, options.Boost
+	q.Operator = strings.ToLower(options.Operator)
+	if q.Operator != "" && q.Operator != "or" && q.Operator != "and" {
+		return nil, fmt.Errorf("%s.%s: operator must be or or and", path, field)
+	}
+	q.MinimumShouldMatch, err = parseMinimumShouldMatch(options.MinimumShouldMatch)
+	if err != nil {
+		return nil, fmt.Errorf("%s.%s.minimum_should_match: %w", path, field, err)
+	}
+	return q, nil
+}
+
+func parseTerm(data json.RawMessage, path string) (Query, error) {
+	field, body, err := fieldClause(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var value interface{}
+	if err = json.Unmarshal(body, &value); err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", path, field, err)
+	}
+	options, isObject := value.(map[string]interface{})
+	if !isObject {
+		if !isScalar(value) {
+			return nil, fmt.Errorf("%s.%s: value must be a string, number or boolean", path, field)
+		}
+		return Term{Field: field, Value: value}, nil
+	}
+
+	var term struct {
+		Value interface{} `json:"value"`
+		Boost float64     `json:"boost"`
+	}
+	if err = decode(body, &term); err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", path, field, err)
+	}
+	if _, ok := options["value"]; !ok || !isScalar(term.Value) {
+		return nil, fmt.Errorf("%s.%s: value must be a string, number or boolean", path, field)
+	}
+	return Term{Field: field, Value: term.Value, Boost: term.Boost}, nil
+}
+
+func parseRange(data json.RawMessage, path string) (Query, error) {
+	field, body, err := fieldClause(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	var bounds struct {
+		GT    interface{} `json:"gt"`
+		GTE   interface{} `json:"gte"`
+		LT    interface{} `json:"lt"`
+		LTE   interface{} `json:"lte"`
+		Boost float64     `json:"boost"`
+	}
+	if err = decode(body, &bounds); err != nil {
+		return nil, fmt.Errorf("%s.%s: %w", path, field, err)
+	}
+
+	q := Range{Field: field, GT: bounds.GT, GTE: bounds.GTE, LT: bounds.LT, LTE: bounds.LTE, Boost: bounds.Boost}
+	if q.GT == nil && q.GTE == nil && q.LT == nil && q.LTE == nil {
+		return nil, fmt.Errorf("%s.%s: needs at least one of gt, gte, lt and lte", path, field)
+	}
+	for _, bound := range []interface{}{q.GT, q.GTE, q.LT, q.LTE} {
+		if !isBound(bound) {
+			return nil, fmt.Errorf(`%s.%s: bounds must be numbers, RFC 3339 dates or "now"`, path, field)
+		}
+	}
+	return q, nil
+}
+
+func parsePrefix(data json.RawMessage, path string) (Query, error) {
+	field, body, err := fieldClause(data, path)
+	if err != nil {
+		return nil, err
+	}
+
+	q := Prefix{Field: field}
+	if err = json.Unmarshal(body, &q.Value); err != nil {
+		var options struct {
+			Value *string `json:"value"`
+			Boost float64 `json:"boost"`
+		}
+		if err = decode(body, &options); err != nil || options.Value == nil {
+			return nil, fmt.Errorf("%s.%s: must be a string or an object with value", path, field)
+		}
+		q.Value, q.Boost = *options.Value, options.Boost
+	}
+	if q.Value == "" {
+		return nil, fmt.Errorf("%s.%s: value must not be empty", path, field)
+	}
+	return q, nil
+}
+
+// fieldClause reads the {"<field>": <body>} form shared by the leaf queries.
+func fieldClause(data json.RawMessage, path string) (string, json.RawMessage, error) {
+	var clause map[string]json.RawMessage
+	if err := json.Unmarshal(data, &clause); err != nil || len(clause) != 1 {
+		return "", nil, fmt.Errorf("%s: must be an object with exactly one field", path)
+	}
+
+	for field, body := range clause {
+		if field == "" {
+			return "", nil, fmt.Errorf("%s: field name must not be empty", path)
+		}
+		return field, body, nil
+	}
+	return "", nil, nil
+}
+
+// parseMinimumShouldMatch accepts a count or a percentage string.
+func parseMinimumShouldMatch(data json.RawMessage) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		return "", err
+	}
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		value, _ := strings.CutSuffix(v, "%")
+		if _, err := strconv.Atoi(value); err != nil {
+			return "", errors.New("must be an integer or a percentage")
+		}
+		return v, nil
+	case float64:
+		if v != float64(int(v)) {
+			return "", errors.New("must be an integer or a percentage")
+		}
+		return fmt.Sprint(int(v)), nil
+	}
+	return "", errors.New("must be an integer or a percentage")
+}
+
+// decode unmarshals data into target, rejecting unknown options.
+func decode(data json.RawMessage, target interface{}) error {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(target)
+}
+
+func isBound(bound interface{}) bool {
+	switch v := bound.(type) {
+	case nil, float64:
+		return true
+	case string:
+		if v == "now" {
+			return true
+		}
+		if _, err := strconv.ParseFloat(v, 64); err == nil {
+			return true
+		}
+		_, err := time.Parse(time.RFC3339, v)
+		return err == nil
+	}
+	return false
+}
+
+func isScalar(value interface{}) bool {
+	switch value.(type) {
+	case string, float64, bool:
+		return true
+	}
+	return false
+}
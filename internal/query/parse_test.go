@@ -0,0 +1,102 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  Query
+	}{
+		{name: "empty", query: "", want: MatchAll{}},
+		{
+			name:  "match shorthand",
+			query: `{"match": {"remark": "rent"}}`,
+			want:  Match{Field: "remark", Query: "rent"},
+		},
+		{
+			name:  "match options",
+			query: `{"match": {"remark": {"query": "rent food", "operator": "AND", "boost": 2}}}`,
+			want:  Match{Field: "remark", Query: "rent food", Operator: "and", Boost: 2},
+		},
+		{
+			name:  "term options",
+			query: `{"term": {"total_amount": {"value": 500, "boost": 3}}}`,
+			want:  Term{Field: "total_amount", Value: 500.0, Boost: 3},
+		},
+		{
+			name:  "range",
+			query: `{"range": {"created_at": {"gte": "2024-01-01T00:00:00Z", "lt": "now"}}}`,
+			want:  Range{Field: "created_at", GTE: "2024-01-01T00:00:00Z", LT: "now"},
+		},
+		{
+			name: "bool",
+			query: `{"bool": {
+				"must": {"match": {"remark": "rent"}},
+				"should": [{"prefix": {"sender_name": "rez"}}, {"match_all": {}}],
+				"must_not": [{"term": {"sender_bank": "bca"}}],
+				"filter": {"range": {"total_amount": {"gt": 100}}},
+				"minimum_should_match": 1
+			}}`,
+			want: Bool{
+				Must:               []Query{Match{Field: "remark", Query: "rent"}},
+				Should:             []Query{Prefix{Field: "sender_name", Value: "rez"}, MatchAll{}},
+				MustNot:            []Query{Term{Field: "sender_bank", Value: "bca"}},
+				Filter:             []Query{Range{Field: "total_amount", GT: 100.0}},
+				MinimumShouldMatch: "1",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.query))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{`[]`, "query: must be an object"},
+		{`{"match": {"a": "x"}, "term": {"b": "y"}}`, "query: must have exactly one query type, got 2"},
+		{`{"fuzzy": {"a": "x"}}`, "query.fuzzy: unknown query type, use bool, match, term, range, prefix or match_all"},
+		{`{"bool": {"must": [{"match": {"a": "x"}}, {"term": {"b": ["y"]}}]}}`, "query.bool.must[1].term.b: value must be a string, number or boolean"},
+		{`{"match": {"a": {"query": "x", "operator": "xor"}}}`, "query.match.a: operator must be or or and"},
+		{`{"range": {"a": {}}}`, "query.range.a: needs at least one of gt, gte, lt and lte"},
+		{`{"range": {"a": {"gt": "yesterday"}}}`, `query.range.a: bounds must be numbers, RFC 3339 dates or "now"`},
+		{`{"prefix": {"a": ""}}`, "query.prefix.a: value must not be empty"},
+		{`{"bool": {"should": {"match_all": {}}, "minimum_should_match": "most"}}`, "query.bool.minimum_should_match: must be an integer or a percentage"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			_, err := Parse([]byte(tt.query))
+			if err == nil || err.Error() != tt.want {
+				t.Errorf("Parse() error = %v, want %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCountTerms(t *testing.T) {
+	q, err := Parse([]byte(`{"bool": {
+		"must": {"match": {"remark": "coffee beans transfer"}},
+		"should": [{"term": {"status": "paid"}}, {"prefix": {"sender_name": "ahm"}}],
+		"filter": {"range": {"total_amount": {"gte": 100}}}}}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if terms := CountTerms(q); terms != 5 {
+		t.Errorf("CountTerms() = %d, want 5", terms)
+	}
+}
@@ -0,0 +1,94 @@
+// Package query is the typed query tree of the JSON search DSL.
+package query
+
+import "strings"
+
+// AllFields makes a match or prefix query search the whole document instead
+// of one field.
+const AllFields = "_all"
+
+// Query is one node of a query tree: Bool, Match, Term, Range, Prefix or
+// MatchAll.
+type Query interface {
+	isQuery()
+}
+
+// Bool combines clauses. Must and Filter clauses are required, only Must
+// clauses score. MustNot clauses exclude documents. Should clauses add to
+// the score; without Must or Filter clauses at least one must match, or
+// MinimumShouldMatch of them when set.
+type Bool struct {
+	Must               []Query
+	Should             []Query
+	MustNot            []Query
+	Filter             []Query
+	MinimumShouldMatch string
+	Boost              float64
+}
+
+// Match tokenizes Query like an indexed document and scores the documents
+// containing its terms in Field. Operator "and" requires every term.
+type Match struct {
+	Field              string
+	Query              string
+	Operator           string
+	MinimumShouldMatch string
+	Boost              float64
+}
+
+// Term matches documents whose field equals Value exactly.
+type Term struct {
+	Field string
+	Value interface{}
+	Boost float64
+}
+
+// Range matches documents whose numeric or date field lies within the
+// bounds. Bounds are numbers, RFC 3339 dates or "now".
+type Range struct {
+	Field string
+	GT    interface{}
+	GTE   interface{}
+	LT    interface{}
+	LTE   interface{}
+	Boost float64
+}
+
+// Prefix matches documents with a term in Field starting with Value.
+type Prefix struct {
+	Field string
+	Value string
+	Boost float64
+}
+
+// MatchAll matches every document.
+type MatchAll struct {
+	Boost float64
+}
+
+// CountTerms returns the number of terms a query looks up: the words of
+// match queries and one for every term and prefix query.
+func CountTerms(q Query) int {
+	switch q := q.(type) {
+	case Bool:
+		terms := 0
+		for _, clauses := range [][]Query{q.Must, q.Should, q.MustNot, q.Filter} {
+			for _, clause := range clauses {
+				terms += CountTerms(clause)
+			}
+		}
+		return terms
+	case Match:
+		return len(strings.Fields(q.Query))
+	case Term, Prefix:
+		return 1
+	}
+	return 0
+}
+
+func (Bool) isQuery()     {}
+func (Match) isQuery()    {}
+func (Term) isQuery()     {}
+func (Range) isQuery()    {}
+func (Prefix) isQuery()   {}
+func (MatchAll) isQuery() {}
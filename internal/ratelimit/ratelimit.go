@@ -0,0 +1,79 @@
+// Package ratelimit keeps the token buckets of the request rate limits,
+// shared by the HTTP and gRPC servers.
+package ratelimit
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"golang.org/x/time/rate"
+)
+
+// Limiter keeps a token bucket per client. Buckets idle long enough to
+// be full again are dropped.
+type Limiter struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// New returns nil for a zero rate, which disables the limit.
+func New(cfg config.RateLimitConfig) *Limiter {
+	if cfg.Rate <= 0 {
+		return nil
+	}
+	burst := cfg.Burst
+	if burst <= 0 {
+		burst = int(math.Ceil(cfg.Rate))
+	}
+	return &Limiter{
+		limit:   rate.Limit(cfg.Rate),
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from the bucket of the client. Without one it returns
+// how long until the next token.
+func (l *Limiter) Allow(client string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b, ok := l.buckets[client]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[client] = b
+	}
+	b.lastSeen = now
+
+	reservation := b.limiter.ReserveN(now, 1)
+	if delay := reservation.DelayFrom(now); delay > 0 {
+		reservation.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	refill := time.Duration(float64(l.burst) / float64(l.limit) * float64(time.Second))
+	if now.Sub(l.lastSweep) < max(refill, time.Minute) {
+		return
+	}
+	l.lastSweep = now
+	for client, b := range l.buckets {
+		if now.Sub(b.lastSeen) > refill {
+			delete(l.buckets, client)
+		}
+	}
+}
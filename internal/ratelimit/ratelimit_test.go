@@ -0,0 +1,33 @@
+package ratelimit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+)
+
+func TestLimiter(t *testing.T) {
+	limiter := New(config.RateLimitConfig{Rate: 2, Burst: 3})
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if allowed, _ := limiter.Allow("a", now); !allowed {
+			t.Fatalf("request %d within the burst was rejected", i+1)
+		}
+	}
+	allowed, retryAfter := limiter.Allow("a", now)
+	if allowed || retryAfter != 500*time.Millisecond {
+		t.Errorf("Allow() over the burst = %v, %v, want false, 500ms", allowed, retryAfter)
+	}
+	if allowed, _ := limiter.Allow("b", now); !allowed {
+		t.Error("another client shares the bucket")
+	}
+	if allowed, _ := limiter.Allow("a", now.Add(500*time.Millisecond)); !allowed {
+		t.Error("the bucket was not refilled")
+	}
+
+	if New(config.RateLimitConfig{}) != nil {
+		t.Error("a zero rate did not disable the limit")
+	}
+}
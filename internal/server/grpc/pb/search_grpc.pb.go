@@ -0,0 +1,271 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.3.0
+// - protoc             v4.25.3
+// source: search.proto
+
+package pb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+const (
+	SearchEngine_Index_FullMethodName     = "/searchengine.SearchEngine/Index"
+	SearchEngine_BulkIndex_FullMethodName = "/searchengine.SearchEngine/BulkIndex"
+	SearchEngine_Delete_FullMethodName    = "/searchengine.SearchEngine/Delete"
+	SearchEngine_Get_FullMethodName       = "/searchengine.SearchEngine/Get"
+	SearchEngine_Search_FullMethodName    = "/searchengine.SearchEngine/Search"
+)
+
+// SearchEngineClient is the client API for SearchEngine service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type SearchEngineClient interface {
+	// Index stores a document, replacing any document with the same id.
+	Index(ctx context.Context, in *IndexRequest, opts ...grpc.CallOption) (*IndexResponse, error)
+	// BulkIndex stores several documents. A document that fails does not stop
+	// the others; its error is listed in the response.
+	BulkIndex(ctx context.Context, in *BulkIndexRequest, opts ...grpc.CallOption) (*BulkIndexResponse, error)
+	// Delete removes a document, NotFound when it does not exist.
+	Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error)
+	// Get returns a stored document, NotFound when it does not exist.
+	Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error)
+	// Search runs either a keyword search for terms or a query of the search
+	// DSL.
+	Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error)
+}
+
+type searchEngineClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewSearchEngineClient(cc grpc.ClientConnInterface) SearchEngineClient {
+	return &searchEngineClient{cc}
+}
+
+func (c *searchEngineClient) Index(ctx context.Context, in *IndexRequest, opts ...grpc.CallOption) (*IndexResponse, error) {
+	out := new(IndexResponse)
+	err := c.cc.Invoke(ctx, SearchEngine_Index_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchEngineClient) BulkIndex(ctx context.Context, in *BulkIndexRequest, opts ...grpc.CallOption) (*BulkIndexResponse, error) {
+	out := new(BulkIndexResponse)
+	err := c.cc.Invoke(ctx, SearchEngine_BulkIndex_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchEngineClient) Delete(ctx context.Context, in *DeleteRequest, opts ...grpc.CallOption) (*DeleteResponse, error) {
+	out := new(DeleteResponse)
+	err := c.cc.Invoke(ctx, SearchEngine_Delete_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchEngineClient) Get(ctx context.Context, in *GetRequest, opts ...grpc.CallOption) (*GetResponse, error) {
+	out := new(GetResponse)
+	err := c.cc.Invoke(ctx, SearchEngine_Get_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *searchEngineClient) Search(ctx context.Context, in *SearchRequest, opts ...grpc.CallOption) (*SearchResponse, error) {
+	out := new(SearchResponse)
+	err := c.cc.Invoke(ctx, SearchEngine_Search_FullMethodName, in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// SearchEngineServer is the server API for SearchEngine service.
+// All implementations must embed UnimplementedSearchEngineServer
+// for forward compatibility
+type SearchEngineServer interface {
+	// Index stores a document, replacing any document with the same id.
+	Index(context.Context, *IndexRequest) (*IndexResponse, error)
+	// BulkIndex stores several documents. A document that fails does not stop
+	// the others; its error is listed in the response.
+	BulkIndex(context.Context, *BulkIndexRequest) (*BulkIndexResponse, error)
+	// Delete removes a document, NotFound when it does not exist.
+	Delete(context.Context, *DeleteRequest) (*DeleteResponse, error)
+	// Get returns a stored document, NotFound when it does not exist.
+	Get(context.Context, *GetRequest) (*GetResponse, error)
+	// Search runs either a keyword search for terms or a query of the search
+	// DSL.
+	Search(context.Context, *SearchRequest) (*SearchResponse, error)
+	mustEmbedUnimplementedSearchEngineServer()
+}
+
+// UnimplementedSearchEngineServer must be embedded to have forward compatible implementations.
+type UnimplementedSearchEngineServer struct {
+}
+
+func (UnimplementedSearchEngineServer) Index(context.Context, *IndexRequest) (*IndexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Index not implemented")
+}
+func (UnimplementedSearchEngineServer) BulkIndex(context.Context, *BulkIndexRequest) (*BulkIndexResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BulkIndex not implemented")
+}
+func (UnimplementedSearchEngineServer) Delete(context.Context, *DeleteRequest) (*DeleteResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Delete not implemented")
+}
+func (UnimplementedSearchEngineServer) Get(context.Context, *GetRequest) (*GetResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Get not implemented")
+}
+func (UnimplementedSearchEngineServer) Search(context.Context, *SearchRequest) (*SearchResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Search not implemented")
+}
+func (UnimplementedSearchEngineServer) mustEmbedUnimplementedSearchEngineServer() {}
+
+// UnsafeSearchEngineServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to SearchEngineServer will
+// result in compilation errors.
+type UnsafeSearchEngineServer interface {
+	mustEmbedUnimplementedSearchEngineServer()
+}
+
+func RegisterSearchEngineServer(s grpc.ServiceRegistrar, srv SearchEngineServer) {
+	s.RegisterService(&SearchEngine_ServiceDesc, srv)
+}
+
+func _SearchEngine_Index_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(IndexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchEngineServer).Index(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SearchEngine_Index_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchEngineServer).Index(ctx, req.(*IndexRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SearchEngine_BulkIndex_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BulkIndexRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchEngineServer).BulkIndex(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SearchEngine_BulkIndex_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchEngineServer).BulkIndex(ctx, req.(*BulkIndexRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SearchEngine_Delete_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchEngineServer).Delete(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SearchEngine_Delete_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchEngineServer).Delete(ctx, req.(*DeleteRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SearchEngine_Get_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchEngineServer).Get(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SearchEngine_Get_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchEngineServer).Get(ctx, req.(*GetRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _SearchEngine_Search_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SearchRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(SearchEngineServer).Search(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: SearchEngine_Search_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(SearchEngineServer).Search(ctx, req.(*SearchRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// SearchEngine_ServiceDesc is the grpc.ServiceDesc for SearchEngine service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var SearchEngine_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "searchengine.SearchEngine",
+	HandlerType: (*SearchEngineServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Index",
+			Handler:    _SearchEngine_Index_Handler,
+		},
+		{
+			MethodName: "BulkIndex",
+			Handler:    _SearchEngine_BulkIndex_Handler,
+		},
+		{
+			MethodName: "Delete",
+			Handler:    _SearchEngine_Delete_Handler,
+		},
+		{
+			MethodName: "Get",
+			Handler:    _SearchEngine_Get_Handler,
+		},
+		{
+			MethodName: "Search",
+			Handler:    _SearchEngine_Search_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "search.proto",
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/grpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+type contextKey int
+
+// apiKeyContextKey holds the auth.Key of an authenticated call.
+const apiKeyContextKey contextKey = iota
+
+// methodScopes are the scopes the RPCs need. Other methods need admin.
+var methodScopes = map[string]auth.Scope{
+	pb.SearchEngine_Index_FullMethodName:     auth.ScopeWrite,
+	pb.SearchEngine_BulkIndex_FullMethodName: auth.ScopeWrite,
+	pb.SearchEngine_Delete_FullMethodName:    auth.ScopeWrite,
+	pb.SearchEngine_Get_FullMethodName:       auth.ScopeRead,
+	pb.SearchEngine_Search_FullMethodName:    auth.ScopeRead,
+}
+
+// AuthInterceptor lets a call through when its API key grants the scope of
+// the RPC and may use the default index, which the service serves. The key
+// is read from the metadata named after the keyring header. A nil keyring
+// lets every call through.
+func AuthInterceptor(keyring *auth.Keyring) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if keyring == nil {
+			return handler(ctx, req)
+		}
+
+		header := strings.ToLower(keyring.Header())
+		var secret string
+		if values := metadata.ValueFromIncomingContext(ctx, header); len(values) > 0 {
+			secret = values[0]
+		}
+		key, ok := keyring.Authenticate(secret)
+		if !ok {
+			return nil, status.Errorf(codes.Unauthenticated, "a valid API key is required in the %s metadata", header)
+		}
+
+		scope, ok := methodScopes[info.FullMethod]
+		if !ok {
+			scope = auth.ScopeAdmin
+		}
+		if !key.HasScope(scope) {
+			return nil, status.Errorf(codes.PermissionDenied, "API key %q lacks the %s scope", key.Name, scope)
+		}
+		if !key.CanAccess(auth.DefaultIndex) {
+			return nil, status.Errorf(codes.PermissionDenied, "API key %q may not use index %q", key.Name, auth.DefaultIndex)
+		}
+		return handler(context.WithValue(ctx, apiKeyContextKey, key), req)
+	}
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/grpc/pb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestAuthInterceptor(t *testing.T) {
+	keyring, err := auth.NewKeyring(config.AuthConfig{Keys: []config.APIKeyConfig{
+		{Name: "reader", Hash: auth.Hash("reader-key"), Scopes: []string{"read"}},
+		{Name: "writer", Hash: auth.Hash("writer-key"), Scopes: []string{"write"}, Indexes: []string{auth.DefaultIndex}},
+		{Name: "transfers", Hash: auth.Hash("transfers-key"), Scopes: []string{"admin"}, Indexes: []string{"transfers"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	interceptor := AuthInterceptor(keyring)
+
+	tests := []struct {
+		name     string
+		secret   string
+		method   string
+		wantCode codes.Code
+	}{
+		{name: "no key", method: pb.SearchEngine_Search_FullMethodName, wantCode: codes.Unauthenticated},
+		{name: "unknown key", secret: "wrong", method: pb.SearchEngine_Search_FullMethodName, wantCode: codes.Unauthenticated},
+		{name: "read", secret: "reader-key", method: pb.SearchEngine_Search_FullMethodName, wantCode: codes.OK},
+		{name: "get", secret: "reader-key", method: pb.SearchEngine_Get_FullMethodName, wantCode: codes.OK},
+		{name: "read key indexes", secret: "reader-key", method: pb.SearchEngine_Index_FullMethodName, wantCode: codes.PermissionDenied},
+		{name: "read key deletes", secret: "reader-key", method: pb.SearchEngine_Delete_FullMethodName, wantCode: codes.PermissionDenied},
+		{name: "write", secret: "writer-key", method: pb.SearchEngine_BulkIndex_FullMethodName, wantCode: codes.OK},
+		{name: "write key searches", secret: "writer-key", method: pb.SearchEngine_Search_FullMethodName, wantCode: codes.PermissionDenied},
+		{name: "unknown method", secret: "writer-key", method: "/searchengine.SearchEngine/Drop", wantCode: codes.PermissionDenied},
+		{name: "other index", secret: "transfers-key", method: pb.SearchEngine_Get_FullMethodName, wantCode: codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if tt.secret != "" {
+				ctx = metadata.NewIncomingContext(ctx, metadata.Pairs("x-api-key", tt.secret))
+			}
+
+			var key auth.Key
+			handler := func(ctx context.Context, _ interface{}) (interface{}, error) {
+				key, _ = ctx.Value(apiKeyContextKey).(auth.Key)
+				return nil, nil
+			}
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tt.method}, handler)
+			if code := status.Code(err); code != tt.wantCode {
+				t.Errorf("code = %v, want %v: %v", code, tt.wantCode, err)
+			}
+			if tt.wantCode == codes.OK && key.Name == "" {
+				t.Error("the key is not in the context of the call")
+			}
+		})
+	}
+}
+
+func TestAuthInterceptorWithoutKeys(t *testing.T) {
+	called := false
+	handler := func(context.Context, interface{}) (interface{}, error) {
+		called = true
+		return nil, nil
+	}
+	info := &grpc.UnaryServerInfo{FullMethod: pb.SearchEngine_Index_FullMethodName}
+	if _, err := AuthInterceptor(nil)(context.Background(), nil, info, handler); err != nil || !called {
+		t.Errorf("call without a keyring = %v, called %v, want it let through", err, called)
+	}
+}
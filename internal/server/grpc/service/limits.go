@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"math"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
+	"github.com/ahmadrezamusthafa/search-engine/internal/ratelimit"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// ServerOptions protects the service like the HTTP API: messages are
+// bounded by limits.max_body_bytes, calls are rate limited per client
+// address before the API key is checked and per API key after.
+func ServerOptions(keyring *auth.Keyring, limits config.LimitsConfig) []grpc.ServerOption {
+	limits = limits.WithDefaults()
+	return []grpc.ServerOption{
+		grpc.MaxRecvMsgSize(int(limits.MaxBodyBytes)),
+		grpc.ChainUnaryInterceptor(
+			limitRate(ratelimit.New(limits.PerIP), clientIP),
+			AuthInterceptor(keyring),
+			limitRate(ratelimit.New(limits.PerKey), clientKey),
+		),
+	}
+}
+
+// limitRate rejects calls over the rate of their client with
+// ResourceExhausted and a retry-after header in seconds. A nil limiter lets
+// every call through.
+func limitRate(limiter *ratelimit.Limiter, client func(ctx context.Context) (string, bool)) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if limiter == nil {
+			return handler(ctx, req)
+		}
+		name, ok := client(ctx)
+		if !ok {
+			return handler(ctx, req)
+		}
+
+		allowed, retryAfter := limiter.Allow(name, time.Now())
+		if !allowed {
+			seconds := int(math.Ceil(retryAfter.Seconds()))
+			_ = grpc.SetHeader(ctx, metadata.Pairs("retry-after", strconv.Itoa(seconds)))
+			return nil, status.Errorf(codes.ResourceExhausted, "too many requests, retry in %d seconds", seconds)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// clientIP is the address the call came from.
+func clientIP(ctx context.Context) (string, bool) {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "", false
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String(), true
+	}
+	return host, true
+}
+
+// clientKey is the name of the API key the call was authenticated with.
+func clientKey(ctx context.Context) (string, bool) {
+	key, ok := ctx.Value(apiKeyContextKey).(auth.Key)
+	return key.Name, ok
+}
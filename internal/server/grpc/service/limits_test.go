@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/grpc/pb"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestClient serves a Badger engine with the options of keyring and
+// limits over an in-memory connection.
+func newTestClient(t *testing.T, keyring *auth.Keyring, limits config.LimitsConfig) pb.SearchEngineClient {
+	t.Helper()
+	db := badgerdb.NewBadgerDB(config.BadgerConfig{Path: t.TempDir()})
+	t.Cleanup(func() { db.Close() })
+	se, err := engine.NewSearchEngine("badger", &config.Config{BM25: config.BM25Config{K1: 1.2, B: 0.75}}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { se.Close() })
+
+	listener := bufconn.Listen(1 << 20)
+	server := grpc.NewServer(ServerOptions(keyring, limits)...)
+	pb.RegisterSearchEngineServer(server, NewServer(se, limits))
+	go server.Serve(listener)
+	t.Cleanup(server.Stop)
+
+	conn, err := grpc.Dial("bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return listener.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewSearchEngineClient(conn)
+}
+
+func TestServerOptionsLimits(t *testing.T) {
+	keyring, err := auth.NewKeyring(config.AuthConfig{Keys: []config.APIKeyConfig{
+		{Name: "first", Hash: auth.Hash("first-key"), Scopes: []string{"admin"}},
+		{Name: "second", Hash: auth.Hash("second-key"), Scopes: []string{"admin"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := newTestClient(t, keyring, config.LimitsConfig{
+		MaxBodyBytes:  256,
+		MaxQueryTerms: 2,
+		PerKey:        config.RateLimitConfig{Rate: 0.001, Burst: 3},
+		PerIP:         config.RateLimitConfig{Rate: 0.001, Burst: 5},
+	})
+	withKey := func(secret string) context.Context {
+		return metadata.AppendToOutgoingContext(context.Background(), "x-api-key", secret)
+	}
+	ctx := withKey("first-key")
+
+	_, err = client.Search(ctx, &pb.SearchRequest{Terms: []string{"alpha", "beta", "gamma"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Search() with 3 terms = %v, want InvalidArgument", err)
+	}
+	_, err = client.Index(ctx, &pb.IndexRequest{Document: &pb.Document{Id: strings.Repeat("a", 300)}})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Index() over max_body_bytes = %v, want ResourceExhausted", err)
+	}
+	if _, err = client.Search(ctx, &pb.SearchRequest{Terms: []string{"alpha"}}); err != nil {
+		t.Fatalf("Search() = %v", err)
+	}
+
+	// The oversized message never reached the interceptors, so the first
+	// key took two tokens from its burst of three.
+	var header metadata.MD
+	_, err = client.Search(ctx, &pb.SearchRequest{Terms: []string{"alpha"}})
+	if err != nil {
+		t.Fatalf("Search() within the burst = %v", err)
+	}
+	_, err = client.Search(ctx, &pb.SearchRequest{Terms: []string{"alpha"}}, grpc.Header(&header))
+	if status.Code(err) != codes.ResourceExhausted || len(header.Get("retry-after")) == 0 {
+		t.Errorf("Search() over the key rate = %v, retry-after %v, want ResourceExhausted", err, header.Get("retry-after"))
+	}
+
+	// The address took four tokens; the fifth call of another key passes,
+	// the sixth is over the address rate even without a valid key.
+	if _, err = client.Search(withKey("second-key"), &pb.SearchRequest{Terms: []string{"alpha"}}); err != nil {
+		t.Fatalf("Search() with another key = %v", err)
+	}
+	_, err = client.Search(context.Background(), &pb.SearchRequest{Terms: []string{"alpha"}})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Search() over the address rate = %v, want ResourceExhausted", err)
+	}
+}
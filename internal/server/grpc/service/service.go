@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/query"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/grpc/pb"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/tokenizer"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// Server implements the SearchEngine gRPC service on top of a search engine.
+type Server struct {
+	pb.UnimplementedSearchEngineServer
+	SearchEngine engine.ISearchEngine
+	// MaxQueryTerms bounds the terms of a search.
+	MaxQueryTerms int
+}
+
+func NewServer(searchEngine engine.ISearchEngine, limits config.LimitsConfig) *Server {
+	return &Server{
+		SearchEngine:  searchEngine,
+		MaxQueryTerms: limits.WithDefaults().MaxQueryTerms,
+	}
+}
+
+func (s *Server) Index(ctx context.Context, req *pb.IndexRequest) (*pb.IndexResponse, error) {
+	if err := s.index(ctx, req.GetDocument()); err != nil {
+		return nil, statusError(err)
+	}
+	return &pb.IndexResponse{}, nil
+}
+
+func (s *Server) BulkIndex(ctx context.Context, req *pb.BulkIndexRequest) (*pb.BulkIndexResponse, error) {
+	response := &pb.BulkIndexResponse{}
+	for _, doc := range req.GetDocuments() {
+		if err := ctx.Err(); err != nil {
+			return nil, statusError(err)
+		}
+		if err := s.index(ctx, doc); err != nil {
+			st := status.Convert(statusError(err))
+			response.Errors = append(response.Errors, &pb.BulkIndexError{
+				Id:      doc.GetId(),
+				Code:    int32(st.Code()),
+				Message: st.Message(),
+			})
+			continue
+		}
+		response.Indexed++
+	}
+	return response, nil
+}
+
+func (s *Server) index(ctx context.Context, doc *pb.Document) error {
+	if doc == nil {
+		return invalidArgument("document is required")
+	}
+
+	document := structs.Document{
+		ID: doc.Id,
+		Content: structs.Content{
+			String:        doc.GetContent().GetString_(),
+			ObjectIndexes: doc.GetContent().GetObjectIndexes(),
+		},
+		StopWords: doc.StopWords,
+		Vector:    doc.Vector,
+	}
+	if object := doc.GetContent().GetObject(); object != nil {
+		document.Content.Object = object.AsMap()
+	}
+	if err := document.Validate(); err != nil {
+		return invalidArgument("%v", err)
+	}
+
+	tokens := tokenizer.Tokenize(document.Content, document.StopWords...)
+	if len(document.Vector) > 0 {
+		return s.SearchEngine.StoreVectorDocument(ctx, document.ID, tokens, document.Vector, document.Content)
+	}
+	return s.SearchEngine.StoreDocument(ctx, document.ID, tokens, document.Content)
+}
+
+func (s *Server) Delete(ctx context.Context, req *pb.DeleteRequest) (*pb.DeleteResponse, error) {
+	err := s.SearchEngine.DeleteDocument(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(documentError(req.GetId(), err))
+	}
+	return &pb.DeleteResponse{}, nil
+}
+
+func (s *Server) Get(ctx context.Context, req *pb.GetRequest) (*pb.GetResponse, error) {
+	doc, err := s.SearchEngine.GetDocument(ctx, req.GetId())
+	if err != nil {
+		return nil, statusError(documentError(req.GetId(), err))
+	}
+
+	response := &pb.GetResponse{
+		Id:          doc.ID,
+		Data:        &structpb.Value{},
+		TokenLength: int32(doc.TokenLength),
+		Ttl:         doc.TTL,
+	}
+	if err = fromJSON(doc.Data, response.Data); err != nil {
+		return nil, statusError(err)
+	}
+	return response, nil
+}
+
+func (s *Server) Search(ctx context.Context, req *pb.SearchRequest) (*pb.SearchResponse, error) {
+	var options structs.SearchOptions
+	if req.Options != nil {
+		data, err := protojson.Marshal(req.Options)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		decoder := json.NewDecoder(bytes.NewReader(data))
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(&options); err != nil {
+			return nil, invalidArgument("options: %v", err)
+		}
+	}
+	if err := engine.ValidateSearchOptions(options); err != nil {
+		return nil, statusError(err)
+	}
+
+	var (
+		result structs.SearchResponse
+		err    error
+	)
+	switch {
+	case len(req.Terms) > 0 && req.Query != nil:
+		return nil, invalidArgument("set either terms or query")
+	case len(req.Terms) > 0:
+		if err = s.checkQueryTerms(len(req.Terms)); err != nil {
+			return nil, err
+		}
+		result, err = s.SearchEngine.SearchWithOptions(ctx, options, req.Terms...)
+	default:
+		// Without a query a kNN search runs alone; with neither every
+		// document matches.
+		var q query.Query
+		if req.Query != nil || options.Knn == nil {
+			var data []byte
+			if req.Query != nil {
+				data, err = protojson.Marshal(req.Query)
+				if err != nil {
+					return nil, statusError(err)
+				}
+			}
+			q, err = query.Parse(data)
+			if err != nil {
+				return nil, invalidArgument("%v", err)
+			}
+			if err = s.checkQueryTerms(query.CountTerms(q)); err != nil {
+				return nil, err
+			}
+		}
+		result, err = s.SearchEngine.SearchQuery(ctx, q, options)
+	}
+	if err != nil {
+		return nil, statusError(err)
+	}
+
+	response := &pb.SearchResponse{
+		Hits:     make([]*pb.Hit, 0, len(result.Hits)),
+		TimedOut: result.TimedOut,
+	}
+	for _, hit := range result.Hits {
+		h, err := toHit(hit)
+		if err != nil {
+			return nil, statusError(err)
+		}
+		response.Hits = append(response.Hits, h)
+	}
+	return response, nil
+}
+
+// checkQueryTerms rejects searches with more than MaxQueryTerms terms.
+func (s *Server) checkQueryTerms(terms int) error {
+	if terms > s.MaxQueryTerms {
+		return invalidArgument("too many query terms: %d, at most %d are allowed", terms, s.MaxQueryTerms)
+	}
+	return nil
+}
+
+func toHit(result structs.SearchResult) (*pb.Hit, error) {
+	hit := &pb.Hit{
+		Id:    result.ID,
+		Score: result.Score,
+		Data:  &structpb.Value{},
+	}
+	if err := fromJSON(result.Data, hit.Data); err != nil {
+		return nil, err
+	}
+	if result.InnerHits != nil {
+		hit.InnerHits = &structpb.Struct{}
+		if err := fromJSON(result.InnerHits, hit.InnerHits); err != nil {
+			return nil, err
+		}
+	}
+	if result.Explanation != nil {
+		hit.Explanation = &structpb.Struct{}
+		if err := fromJSON(result.Explanation, hit.Explanation); err != nil {
+			return nil, err
+		}
+	}
+	return hit, nil
+}
+
+// fromJSON converts a value to a protobuf message through its JSON form,
+// which is the form the HTTP API returns.
+func fromJSON(value interface{}, message proto.Message) error {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return protojson.Unmarshal(data, message)
+}
+
+// documentError names the missing document in a not found error.
+func documentError(docID string, err error) error {
+	if errors.Is(err, engine.ErrDocumentNotFound) {
+		return &engine.Error{Kind: engine.ErrNotFound, Err: fmt.Errorf("document %q not found", docID)}
+	}
+	return err
+}
+
+func invalidArgument(format string, args ...interface{}) error {
+	return status.Errorf(codes.InvalidArgument, format, args...)
+}
+
+// statusError translates the kind of an engine error to a gRPC status.
+// Errors that already are a status are kept.
+func statusError(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+
+	code := codes.Internal
+	switch {
+	case errors.Is(err, engine.ErrInvalid):
+		code = codes.InvalidArgument
+	case errors.Is(err, engine.ErrNotFound):
+		code = codes.NotFound
+	case errors.Is(err, engine.ErrUnavailable):
+		code = codes.Unavailable
+	case errors.Is(err, engine.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		code = codes.DeadlineExceeded
+	case errors.Is(err, context.Canceled):
+		code = codes.Canceled
+	}
+	return status.Error(code, err.Error())
+}
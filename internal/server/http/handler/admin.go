@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"fmt"
+	apiresponse "github.com/ahmadrezamusthafa/search-engine/common/api-response"
+	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// BackupHandler streams a snapshot of the whole database. The version to use
+// as `since` for the next incremental backup is sent in the X-Backup-Version
+// trailer, since it is only known once the stream is complete.
+func (h *Handler) BackupHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	defer func() {
+		if err != nil {
+			response := apiresponse.APIResponse{
+				Status:  "error",
+				Message: util.CapitalizeFirstWord(err.Error()),
+			}
+			apiresponse.RespondJSON(w, http.StatusBadRequest, response)
+		}
+	}()
+
+	var since uint64
+	if sinceParam := r.URL.Query().Get("since"); sinceParam != "" {
+		since, err = strconv.ParseUint(sinceParam, 10, 64)
+		if err != nil {
+			return
+		}
+	}
+
+	w.Header().Set("Trailer", "X-Backup-Version")
+	stream := &trackingWriter{w: w}
+	version, backupErr := h.Snapshotter.Backup(stream, since)
+	if backupErr != nil {
+		if !stream.written {
+			err = backupErr
+			return
+		}
+		// The status line is already out, all we can do is cut the stream.
+		log.Println(backupErr)
+		return
+	}
+	if !stream.written {
+		w.Header().Set("Content-Type", "application/octet-stream")
+		w.WriteHeader(http.StatusOK)
+	}
+	w.Header().Set("X-Backup-Version", strconv.FormatUint(version, 10))
+}
+
+// RestoreHandler loads a backup into the live database. A full restore
+// replaces everything; with `incremental=true` the backup is applied over
+// the previous restore. Writes wait until the restore is done.
+func (h *Handler) RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err    error
+		status = http.StatusBadRequest
+	)
+	defer func() {
+		if err != nil {
+			response := apiresponse.APIResponse{
+				Status:  "error",
+				Message: util.CapitalizeFirstWord(err.Error()),
+			}
+			apiresponse.RespondJSON(w, status, response)
+		}
+	}()
+	defer r.Body.Close()
+
+	var incremental bool
+	if incrementalParam := r.URL.Query().Get("incremental"); incrementalParam != "" {
+		incremental, err = strconv.ParseBool(incrementalParam)
+		if err != nil {
+			err = fmt.Errorf("invalid incremental %q: must be true or false", incrementalParam)
+			return
+		}
+	}
+
+	err = engine.Restore(h.Snapshotter, r.Body, incremental, h.SearchEngine, h.Indexes)
+	if err != nil {
+		status = http.StatusInternalServerError
+		return
+	}
+
+	response := apiresponse.APIResponse{
+		Status:  "success",
+		Message: "Restored successfully",
+	}
+	apiresponse.RespondJSON(w, http.StatusOK, response)
+}
+
+// trackingWriter remembers whether anything reached the client, which decides
+// if an error can still be reported as a JSON response.
+type trackingWriter struct {
+	w       http.ResponseWriter
+	written bool
+}
+
+func (t *trackingWriter) Write(p []byte) (int, error) {
+	if !t.written {
+		t.w.Header().Set("Content-Type", "application/octet-stream")
+		t.written = true
+	}
+	return t.w.Write(p)
+}
@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	apiresponse "github.com/ahmadrezamusthafa/search-engine/common/api-response"
+	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// SimilarHandler finds documents similar to the stored document {id} in the
+// default index, or in the index named by the `index` parameter.
+func (h *Handler) SimilarHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err    error
+		status = http.StatusBadRequest
+	)
+	defer func() {
+		if err != nil {
+			response := apiresponse.APIResponse{
+				Status:  "error",
+				Message: util.CapitalizeFirstWord(err.Error()),
+			}
+			apiresponse.RespondJSON(w, status, response)
+		}
+	}()
+
+	searchEngine, ok := h.resolveIndex(w, r.URL.Query().Get("index"))
+	if !ok {
+		return
+	}
+
+	options, err := parseSearchOptions(r)
+	if err != nil {
+		return
+	}
+
+	maxQueryTerms := min(engine.DefaultMaxQueryTerms, h.MaxQueryTerms)
+	if value := r.URL.Query().Get("max_query_terms"); value != "" {
+		maxQueryTerms, err = strconv.Atoi(value)
+		if err != nil || maxQueryTerms <= 0 {
+			err = fmt.Errorf("invalid max_query_terms %q: must be a positive integer", value)
+			return
+		}
+		if err = h.checkQueryTerms(maxQueryTerms); err != nil {
+			return
+		}
+	}
+
+	docID := mux.Vars(r)["id"]
+	result, err := searchEngine.MoreLikeThis(r.Context(), docID, maxQueryTerms, options)
+	if errors.Is(err, engine.ErrDocumentNotFound) {
+		err = &engine.Error{Kind: engine.ErrNotFound, Err: fmt.Errorf("document %q not found", docID)}
+	}
+	if err != nil {
+		status = errorStatus(err)
+		return
+	}
+
+	response := apiresponse.APIResponse{
+		Status:   "success",
+		Data:     result.Hits,
+		TimedOut: result.TimedOut,
+	}
+	apiresponse.RespondJSON(w, http.StatusOK, response)
+}
+
+// DocumentHandler returns the stored document {id} with its token length and
+// remaining TTL, from the default index or the one named by `index`.
+func (h *Handler) DocumentHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err    error
+		status = http.StatusInternalServerError
+	)
+	defer func() {
+		if err != nil {
+			response := apiresponse.APIResponse{
+				Status:  "error",
+				Message: util.CapitalizeFirstWord(err.Error()),
+			}
+			apiresponse.RespondJSON(w, status, response)
+		}
+	}()
+
+	searchEngine, ok := h.resolveIndex(w, r.URL.Query().Get("index"))
+	if !ok {
+		return
+	}
+
+	docID := mux.Vars(r)["id"]
+	doc, err := searchEngine.GetDocument(r.Context(), docID)
+	if errors.Is(err, engine.ErrDocumentNotFound) {
+		err = &engine.Error{Kind: engine.ErrNotFound, Err: fmt.Errorf("document %q not found", docID)}
+	}
+	if err != nil {
+		status = errorStatus(err)
+		return
+	}
+
+	response := apiresponse.APIResponse{
+		Status: "success",
+		Data:   doc,
+	}
+	apiresponse.RespondJSON(w, http.StatusOK, response)
+}
+
+// DocumentExistsHandler answers 200 when the document {id} exists and 404
+// otherwise, without a body.
+func (h *Handler) DocumentExistsHandler(w http.ResponseWriter, r *http.Request) {
+	searchEngine, ok := h.resolveIndex(w, r.URL.Query().Get("index"))
+	if !ok {
+		return
+	}
+
+	exists, err := searchEngine.DocumentExists(r.Context(), mux.Vars(r)["id"])
+	switch {
+	case err != nil:
+		w.WriteHeader(errorStatus(err))
+	case !exists:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
@@ -1,15 +1,83 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"github.com/ahmadrezamusthafa/search-engine/config"
 	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/health"
+	"io"
+	"net/http"
 )
 
 type Handler struct {
 	SearchEngine engine.ISearchEngine
+	Indexes      *engine.IndexRegistry
+	Snapshotter  engine.ISnapshotter
+	// Health checks the dependencies for the readiness probe.
+	Health *health.Checker
+	// MaxBodyBytes bounds the JSON bodies of index, search and settings
+	// requests. Backup, restore and import streams are not bounded.
+	MaxBodyBytes int64
+	// MaxQueryTerms bounds the terms of a search.
+	MaxQueryTerms int
 }
 
-func NewHandler(searchEngine engine.ISearchEngine) *Handler {
+func NewHandler(searchEngine engine.ISearchEngine, indexes *engine.IndexRegistry, snapshotter engine.ISnapshotter, checker *health.Checker, limits config.LimitsConfig) *Handler {
+	limits = limits.WithDefaults()
 	return &Handler{
-		SearchEngine: searchEngine,
+		SearchEngine:  searchEngine,
+		Indexes:       indexes,
+		Snapshotter:   snapshotter,
+		Health:        checker,
+		MaxBodyBytes:  limits.MaxBodyBytes,
+		MaxQueryTerms: limits.MaxQueryTerms,
 	}
 }
+
+// errorStatus translates the kind of an engine error to an HTTP status.
+func errorStatus(err error) int {
+	var tooLarge bodyTooLargeError
+	switch {
+	case errors.As(err, &tooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, engine.ErrInvalid):
+		return http.StatusBadRequest
+	case errors.Is(err, engine.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, engine.ErrUnavailable):
+		return http.StatusServiceUnavailable
+	case errors.Is(err, engine.ErrTimeout):
+		return http.StatusGatewayTimeout
+	}
+	return http.StatusInternalServerError
+}
+
+// bodyTooLargeError rejects a request body over the size limit.
+type bodyTooLargeError struct {
+	limit int64
+}
+
+func (e bodyTooLargeError) Error() string {
+	return fmt.Sprintf("request body must not exceed %d bytes", e.limit)
+}
+
+// readBody reads a JSON request body of at most MaxBodyBytes bytes.
+func (h *Handler) readBody(w http.ResponseWriter, r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, h.MaxBodyBytes))
+	var maxBytesErr *http.MaxBytesError
+	if errors.As(err, &maxBytesErr) {
+		return nil, bodyTooLargeError{limit: maxBytesErr.Limit}
+	}
+	return body, err
+}
+
+// checkQueryTerms rejects searches with more than MaxQueryTerms terms.
+func (h *Handler) checkQueryTerms(terms int) error {
+	if terms > h.MaxQueryTerms {
+		return fmt.Errorf("too many query terms: %d, at most %d are allowed", terms, h.MaxQueryTerms)
+	}
+	return nil
+}
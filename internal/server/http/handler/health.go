@@ -0,0 +1,38 @@
+package handler
+
+import (
+	apiresponse "github.com/ahmadrezamusthafa/search-engine/common/api-response"
+	"github.com/ahmadrezamusthafa/search-engine/internal/health"
+	"net/http"
+)
+
+// LivenessHandler answers as long as the process serves requests.
+func (h *Handler) LivenessHandler(w http.ResponseWriter, r *http.Request) {
+	response := apiresponse.APIResponse{
+		Status:  "success",
+		Message: "OK",
+	}
+	apiresponse.RespondJSON(w, http.StatusOK, response)
+}
+
+// ReadinessHandler checks the dependencies and reports each of them. It
+// answers 503 while any is down.
+func (h *Handler) ReadinessHandler(w http.ResponseWriter, r *http.Request) {
+	results, ready := h.Health.Check(r.Context())
+	if !ready {
+		response := apiresponse.APIResponse{
+			Status:  "error",
+			Message: "Not ready, down: " + health.Down(results),
+			Data:    results,
+		}
+		apiresponse.RespondJSON(w, http.StatusServiceUnavailable, response)
+		return
+	}
+
+	response := apiresponse.APIResponse{
+		Status:  "success",
+		Message: "Ready",
+		Data:    results,
+	}
+	apiresponse.RespondJSON(w, http.StatusOK, response)
+}
@@ -4,37 +4,71 @@ import (
 	"encoding/json"
 	apiresponse "github.com/ahmadrezamusthafa/search-engine/common/api-response"
 	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/http/openapi"
 	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
 	"github.com/ahmadrezamusthafa/search-engine/pkg/tokenizer"
-	"io"
 	"net/http"
 )
 
+// IndexHandler stores a document into the default index, or into the one
+// named by `index`.
 func (h *Handler) IndexHandler(w http.ResponseWriter, r *http.Request) {
-	var err error
+	searchEngine, ok := h.resolveIndex(w, r.URL.Query().Get("index"))
+	if !ok {
+		return
+	}
+	h.indexDocument(searchEngine, w, r)
+}
+
+func (h *Handler) indexDocument(searchEngine engine.ISearchEngine, w http.ResponseWriter, r *http.Request) {
+	var (
+		err    error
+		status = http.StatusBadRequest
+	)
 	defer func() {
 		if err != nil {
 			response := apiresponse.APIResponse{
 				Status:  "error",
 				Message: util.CapitalizeFirstWord(err.Error()),
 			}
-			apiresponse.RespondJSON(w, http.StatusInternalServerError, response)
+			apiresponse.RespondJSON(w, status, response)
 		}
 	}()
 
-	body, err := io.ReadAll(r.Body)
+	body, err := h.readBody(w, r)
 	if err != nil {
+		status = errorStatus(err)
 		return
 	}
-	defer r.Body.Close()
 
+	// openapi.json declares the shape of a document; object_indexes naming
+	// fields of the object is the one rule a schema cannot express.
+	var value interface{}
+	if err = json.Unmarshal(body, &value); err != nil {
+		return
+	}
+	if err = openapi.ValidateSchema("Document", value); err != nil {
+		return
+	}
 	var doc structs.Document
 	if err = json.Unmarshal(body, &doc); err != nil {
 		return
 	}
+	if err = doc.Content.Validate(); err != nil {
+		return
+	}
 
 	tokens := tokenizer.Tokenize(doc.Content, doc.StopWords...)
-	h.SearchEngine.StoreDocument(doc.ID, tokens, doc.Content)
+	if doc.Vector != nil {
+		err = searchEngine.StoreVectorDocument(r.Context(), doc.ID, tokens, doc.Vector, doc.Content)
+	} else {
+		err = searchEngine.StoreDocument(r.Context(), doc.ID, tokens, doc.Content)
+	}
+	if err != nil {
+		status = errorStatus(err)
+		return
+	}
 
 	response := apiresponse.APIResponse{
 		Status:  "success",
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+)
+
+func newTestHandler(t *testing.T, limits config.LimitsConfig) *Handler {
+	t.Helper()
+	db := badgerdb.NewBadgerDB(config.BadgerConfig{Path: t.TempDir()})
+	t.Cleanup(func() { db.Close() })
+	se, err := engine.NewSearchEngine("badger", &config.Config{BM25: config.BM25Config{K1: 1.2, B: 0.75}}, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { se.Close() })
+	return NewHandler(se, nil, nil, nil, limits)
+}
+
+// TestIndexHandlerValidation pins the rules the Document schema of
+// openapi.json declares.
+func TestIndexHandlerValidation(t *testing.T) {
+	h := newTestHandler(t, config.LimitsConfig{MaxBodyBytes: 128})
+
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+	}{
+		{
+			name:       "valid",
+			body:       `{"id": "1", "content": {"object": {"name": "alpha"}, "object_indexes": ["name"]}}`,
+			wantStatus: http.StatusOK,
+		},
+		{name: "missing id", body: `{"content": {"string": "alpha"}}`, wantStatus: http.StatusBadRequest},
+		{name: "empty id", body: `{"id": "", "content": {"string": "alpha"}}`, wantStatus: http.StatusBadRequest},
+		{
+			name:       "unknown object_indexes",
+			body:       `{"id": "1", "content": {"object": {"name": "alpha"}, "object_indexes": ["city"]}}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{name: "unknown field", body: `{"id": "1", "title": "alpha"}`, wantStatus: http.StatusBadRequest},
+		{name: "unknown content field", body: `{"id": "1", "content": {"text": "alpha"}}`, wantStatus: http.StatusBadRequest},
+		{name: "malformed", body: `{"id": "1"`, wantStatus: http.StatusBadRequest},
+		{name: "numeric id", body: `{"id": 1}`, wantStatus: http.StatusBadRequest},
+		{name: "numeric stop word", body: `{"id": "1", "stop_words": [1]}`, wantStatus: http.StatusBadRequest},
+		{name: "empty vector", body: `{"id": "1", "vector": []}`, wantStatus: http.StatusBadRequest},
+		{
+			name:       "oversized body",
+			body:       `{"id": "1", "content": {"string": "` + strings.Repeat("alpha ", 30) + `"}}`,
+			wantStatus: http.StatusRequestEntityTooLarge,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/index", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.IndexHandler(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+
+	if exists, err := h.SearchEngine.DocumentExists(context.Background(), "1"); err != nil || !exists {
+		t.Errorf("DocumentExists(1) = %v, %v, want the valid document stored", exists, err)
+	}
+}
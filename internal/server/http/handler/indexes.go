@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	apiresponse "github.com/ahmadrezamusthafa/search-engine/common/api-response"
+	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/gorilla/mux"
+	"net/http"
+)
+
+func (h *Handler) PutIndexHandler(w http.ResponseWriter, r *http.Request) {
+	var err error
+	statusCode := http.StatusBadRequest
+	defer func() {
+		if err != nil {
+			response := apiresponse.APIResponse{
+				Status:  "error",
+				Message: util.CapitalizeFirstWord(err.Error()),
+			}
+			apiresponse.RespondJSON(w, statusCode, response)
+		}
+	}()
+
+	body, err := h.readBody(w, r)
+	if err != nil {
+		statusCode = errorStatus(err)
+		return
+	}
+
+	// Decoding over the defaults keeps an explicit zero k1 or b apart from
+	// an omitted one.
+	name := mux.Vars(r)["name"]
+	settings := engine.IndexSettings{BM25: h.Indexes.DefaultSettings(name).BM25}
+	if len(body) > 0 {
+		if err = json.Unmarshal(body, &settings); err != nil {
+			return
+		}
+	}
+
+	created, err := h.Indexes.PutIndex(name, settings)
+	if err != nil {
+		statusCode = errorStatus(err)
+		return
+	}
+
+	settings, _ = h.Indexes.GetSettings(name)
+	response := apiresponse.APIResponse{
+		Status:  "success",
+		Message: "Index updated successfully",
+		Data:    settings,
+	}
+	if created {
+		response.Message = "Index created successfully"
+		apiresponse.RespondJSON(w, http.StatusCreated, response)
+		return
+	}
+	apiresponse.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) ListIndexesHandler(w http.ResponseWriter, r *http.Request) {
+	response := apiresponse.APIResponse{
+		Status: "success",
+		Data:   h.Indexes.ListIndexes(),
+	}
+	apiresponse.RespondJSON(w, http.StatusOK, response)
+}
+
+func (h *Handler) IndexDocsHandler(w http.ResponseWriter, r *http.Request) {
+	searchEngine, ok := h.getIndex(w, r)
+	if !ok {
+		return
+	}
+	h.indexDocument(searchEngine, w, r)
+}
+
+func (h *Handler) SearchIndexHandler(w http.ResponseWriter, r *http.Request) {
+	searchEngine, ok := h.getIndex(w, r)
+	if !ok {
+		return
+	}
+	h.search(searchEngine, w, r)
+}
+
+func (h *Handler) QueryIndexHandler(w http.ResponseWriter, r *http.Request) {
+	searchEngine, ok := h.getIndex(w, r)
+	if !ok {
+		return
+	}
+	h.searchQuery(searchEngine, w, r)
+}
+
+func (h *Handler) getIndex(w http.ResponseWriter, r *http.Request) (engine.ISearchEngine, bool) {
+	return h.resolveIndex(w, mux.Vars(r)["name"])
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"fmt"
+	apiresponse "github.com/ahmadrezamusthafa/search-engine/common/api-response"
+	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/migration"
+	"log"
+	"net/http"
+)
+
+// ExportHandler streams every document of the default index, or of the index
+// named by the `index` parameter, as JSON lines.
+func (h *Handler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	searchEngine, ok := h.resolveIndex(w, r.URL.Query().Get("index"))
+	if !ok {
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	count, err := migration.Export(r.Context(), searchEngine, w)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	log.Printf("Exported %d documents", count)
+}
+
+// ImportHandler stores every JSON lines document of the request body into the
+// default index, or into the index named by the `index` parameter.
+func (h *Handler) ImportHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err    error
+		status = http.StatusBadRequest
+	)
+	defer func() {
+		if err != nil {
+			response := apiresponse.APIResponse{
+				Status:  "error",
+				Message: util.CapitalizeFirstWord(err.Error()),
+			}
+			apiresponse.RespondJSON(w, status, response)
+		}
+	}()
+	defer r.Body.Close()
+
+	searchEngine, ok := h.resolveIndex(w, r.URL.Query().Get("index"))
+	if !ok {
+		return
+	}
+
+	count, err := migration.Import(r.Context(), searchEngine, r.Body)
+	if err != nil {
+		status = errorStatus(err)
+		err = fmt.Errorf("imported %d documents before failing: %w", count, err)
+		return
+	}
+
+	response := apiresponse.APIResponse{
+		Status:  "success",
+		Message: fmt.Sprintf("Imported %d documents", count),
+		Data:    map[string]int{"imported": count},
+	}
+	apiresponse.RespondJSON(w, http.StatusOK, response)
+}
+
+// resolveIndex returns the default engine for an empty name, or the named
+// index, answering 404 itself when the index does not exist.
+func (h *Handler) resolveIndex(w http.ResponseWriter, name string) (engine.ISearchEngine, bool) {
+	if name == "" {
+		return h.SearchEngine, true
+	}
+
+	searchEngine, ok := h.Indexes.GetIndex(name)
+	if !ok {
+		response := apiresponse.APIResponse{
+			Status:  "error",
+			Message: fmt.Sprintf("Index %q not found", name),
+		}
+		apiresponse.RespondJSON(w, http.StatusNotFound, response)
+	}
+	return searchEngine, ok
+}
@@ -0,0 +1,40 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+)
+
+func TestImportHandlerStatus(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		closed     bool
+		wantStatus int
+	}{
+		{name: "imported", body: `{"id": "1", "tokens": ["alpha"]}`, wantStatus: http.StatusOK},
+		{name: "malformed line", body: `{"id": "1", "tokens": ["alpha"]}` + "\n{\"id\": ", wantStatus: http.StatusBadRequest},
+		{name: "wrong type", body: `{"id": 1}`, wantStatus: http.StatusBadRequest},
+		{name: "missing id", body: `{"tokens": ["alpha"]}`, wantStatus: http.StatusBadRequest},
+		{name: "storage unavailable", body: `{"id": "1", "tokens": ["alpha"]}`, closed: true, wantStatus: http.StatusServiceUnavailable},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := newTestHandler(t, config.LimitsConfig{})
+			if tt.closed {
+				h.SearchEngine.Close()
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/admin/import", strings.NewReader(tt.body))
+			rec := httptest.NewRecorder()
+			h.ImportHandler(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
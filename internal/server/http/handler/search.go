@@ -1,34 +1,191 @@
 package handler
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	apiresponse "github.com/ahmadrezamusthafa/search-engine/common/api-response"
 	"github.com/ahmadrezamusthafa/search-engine/common/util"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/query"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
 	"net/http"
 )
 
+// SearchHandler searches the default index, or the one named by `index`.
 func (h *Handler) SearchHandler(w http.ResponseWriter, r *http.Request) {
-	var err error
+	searchEngine, ok := h.resolveIndex(w, r.URL.Query().Get("index"))
+	if !ok {
+		return
+	}
+	h.search(searchEngine, w, r)
+}
+
+func (h *Handler) search(searchEngine engine.ISearchEngine, w http.ResponseWriter, r *http.Request) {
+	var (
+		err    error
+		status = http.StatusBadRequest
+	)
+	defer func() {
+		if err != nil {
+			response := apiresponse.APIResponse{
+				Status:  "error",
+				Message: util.CapitalizeFirstWord(err.Error()),
+			}
+			apiresponse.RespondJSON(w, status, response)
+		}
+	}()
+
+	options, err := parseSearchOptions(r)
+	if err != nil {
+		return
+	}
+
+	queries := r.URL.Query()["query"]
+	if len(queries) == 0 && options.Knn == nil {
+		err = errors.New("query parameter 'query' is required")
+		return
+	}
+	if err = h.checkQueryTerms(len(queries)); err != nil {
+		return
+	}
+
+	result, err := searchEngine.SearchWithOptions(r.Context(), options, queries...)
+	if err != nil {
+		status = errorStatus(err)
+		return
+	}
+
+	response := apiresponse.APIResponse{
+		Status:   "success",
+		Data:     result.Hits,
+		TimedOut: result.TimedOut,
+	}
+	apiresponse.RespondJSON(w, http.StatusOK, response)
+}
+
+// QueryHandler runs a search DSL query on the default index, or on the one
+// named by `index`.
+func (h *Handler) QueryHandler(w http.ResponseWriter, r *http.Request) {
+	searchEngine, ok := h.resolveIndex(w, r.URL.Query().Get("index"))
+	if !ok {
+		return
+	}
+	h.searchQuery(searchEngine, w, r)
+}
+
+// searchRequest is the body of a POST search: a query of the search DSL
+// next to the search options.
+type searchRequest struct {
+	Query json.RawMessage `json:"query"`
+	structs.SearchOptions
+}
+
+func (h *Handler) searchQuery(searchEngine engine.ISearchEngine, w http.ResponseWriter, r *http.Request) {
+	var (
+		err    error
+		status = http.StatusBadRequest
+	)
+	defer func() {
+		if err != nil {
+			response := apiresponse.APIResponse{
+				Status:  "error",
+				Message: util.CapitalizeFirstWord(err.Error()),
+			}
+			apiresponse.RespondJSON(w, status, response)
+		}
+	}()
+
+	body, err := h.readBody(w, r)
+	if err != nil {
+		status = errorStatus(err)
+		return
+	}
+
+	var request searchRequest
+	if len(bytes.TrimSpace(body)) > 0 {
+		decoder := json.NewDecoder(bytes.NewReader(body))
+		decoder.DisallowUnknownFields()
+		if err = decoder.Decode(&request); err != nil {
+			return
+		}
+	}
+	if err = engine.ValidateSearchOptions(request.SearchOptions); err != nil {
+		return
+	}
+
+	// Without a query a kNN search runs alone; with neither every
+	// document matches.
+	var q query.Query
+	if len(request.Query) > 0 || request.Knn == nil {
+		q, err = query.Parse(request.Query)
+		if err != nil {
+			return
+		}
+		if err = h.checkQueryTerms(query.CountTerms(q)); err != nil {
+			return
+		}
+	}
+
+	result, err := searchEngine.SearchQuery(r.Context(), q, request.SearchOptions)
+	if err != nil {
+		status = errorStatus(err)
+		return
+	}
+
+	response := apiresponse.APIResponse{
+		Status:   "success",
+		Data:     result.Hits,
+		TimedOut: result.TimedOut,
+	}
+	apiresponse.RespondJSON(w, http.StatusOK, response)
+}
+
+// CountHandler returns the number of hits of a `query` search without
+// fetching the hits, from the default index or the one named by `index`.
+func (h *Handler) CountHandler(w http.ResponseWriter, r *http.Request) {
+	var (
+		err    error
+		status = http.StatusBadRequest
+	)
 	defer func() {
 		if err != nil {
 			response := apiresponse.APIResponse{
 				Status:  "error",
 				Message: util.CapitalizeFirstWord(err.Error()),
 			}
-			apiresponse.RespondJSON(w, http.StatusInternalServerError, response)
+			apiresponse.RespondJSON(w, status, response)
 		}
 	}()
 
+	searchEngine, ok := h.resolveIndex(w, r.URL.Query().Get("index"))
+	if !ok {
+		return
+	}
+
+	options, err := parseSearchOptions(r)
+	if err != nil {
+		return
+	}
+
 	queries := r.URL.Query()["query"]
 	if len(queries) == 0 {
 		err = errors.New("query parameter 'query' is required")
 		return
 	}
+	if err = h.checkQueryTerms(len(queries)); err != nil {
+		return
+	}
+
+	count, err := searchEngine.Count(r.Context(), options, queries...)
+	if err != nil {
+		status = errorStatus(err)
+		return
+	}
 
-	results := h.SearchEngine.Search(queries...)
 	response := apiresponse.APIResponse{
 		Status: "success",
-		Data:   results,
+		Data:   map[string]int{"count": count},
 	}
 	apiresponse.RespondJSON(w, http.StatusOK, response)
 }
@@ -0,0 +1,130 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/structs"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+func parseSearchOptions(r *http.Request) (structs.SearchOptions, error) {
+	var options structs.SearchOptions
+	var err error
+
+	params := r.URL.Query()
+	if fields := params["fields"]; len(fields) > 0 {
+		options.Fields, err = parseFieldBoosts(fields)
+		if err != nil {
+			return options, err
+		}
+	}
+
+	options.MinimumShouldMatch = params.Get("minimum_should_match")
+	if minScore := params.Get("min_score"); minScore != "" {
+		options.MinScore, err = strconv.ParseFloat(minScore, 64)
+		if err != nil {
+			return options, fmt.Errorf("invalid min_score %q: must be a number", minScore)
+		}
+	}
+
+	options.Collapse = params.Get("collapse")
+	for name, target := range map[string]*int{"from": &options.From, "size": &options.Size} {
+		if value := params.Get(name); value != "" {
+			*target, err = strconv.Atoi(value)
+			if err != nil {
+				return options, fmt.Errorf("invalid %s %q: must be an integer", name, value)
+			}
+		}
+	}
+
+	if explain := params.Get("explain"); explain != "" {
+		options.Explain, err = strconv.ParseBool(explain)
+		if err != nil {
+			return options, fmt.Errorf("invalid explain %q: must be true or false", explain)
+		}
+	}
+
+	if functionScore := params.Get("function_score"); functionScore != "" {
+		options.FunctionScore = new(structs.FunctionScore)
+		err = json.Unmarshal([]byte(functionScore), options.FunctionScore)
+		if err != nil {
+			return options, fmt.Errorf("invalid function_score: %w", err)
+		}
+	}
+
+	if rescore := params.Get("rescore"); rescore != "" {
+		options.Rescore = new(structs.Rescore)
+		err = json.Unmarshal([]byte(rescore), options.Rescore)
+		if err != nil {
+			return options, fmt.Errorf("invalid rescore: %w", err)
+		}
+	}
+
+	if knn := params.Get("knn"); knn != "" {
+		options.Knn = new(structs.Knn)
+		err = json.Unmarshal([]byte(knn), options.Knn)
+		if err != nil {
+			return options, fmt.Errorf("invalid knn: %w", err)
+		}
+	}
+
+	for _, value := range params["sort"] {
+		options.Sort = append(options.Sort, parseSortFields(value)...)
+	}
+
+	if source := params.Get("_source"); source != "" {
+		options.Source = parseSourceFilter(source)
+	}
+	options.Timeout = params.Get("timeout")
+
+	return options, engine.ValidateSearchOptions(options)
+}
+
+// parseSortFields reads comma separated `field` or `field:order` entries.
+func parseSortFields(value string) []structs.SortField {
+	var fields []structs.SortField
+	for _, entry := range strings.Split(value, ",") {
+		field, order, _ := strings.Cut(strings.TrimSpace(entry), ":")
+		fields = append(fields, structs.SortField{Field: field, Order: strings.ToLower(order)})
+	}
+	return fields
+}
+
+// parseSourceFilter reads true, false or comma separated fields.
+func parseSourceFilter(value string) *structs.SourceFilter {
+	if enabled, err := strconv.ParseBool(value); err == nil {
+		return &structs.SourceFilter{Disabled: !enabled}
+	}
+	return &structs.SourceFilter{Includes: strings.Split(value, ",")}
+}
+
+// parseFieldBoosts reads `field^boost` entries, separated by spaces, commas or
+// repeated parameters. A field without boost gets 1.
+func parseFieldBoosts(values []string) (map[string]float64, error) {
+	boosts := make(map[string]float64)
+	for _, value := range values {
+		entries := strings.FieldsFunc(value, func(r rune) bool {
+			return r == ' ' || r == ','
+		})
+		for _, entry := range entries {
+			field, boostParam, hasBoost := strings.Cut(entry, "^")
+			if field == "" {
+				return nil, fmt.Errorf("invalid field boost %q", entry)
+			}
+
+			boost := 1.0
+			if hasBoost {
+				var err error
+				boost, err = strconv.ParseFloat(boostParam, 64)
+				if err != nil || boost <= 0 {
+					return nil, fmt.Errorf("invalid boost in %q: must be a positive number", entry)
+				}
+			}
+			boosts[field] = boost
+		}
+	}
+	return boosts, nil
+}
@@ -0,0 +1,51 @@
+// Package openapi holds the OpenAPI document of the HTTP API. The handlers
+// validate request bodies against its schemas, so the document is the single
+// source of the rules it declares.
+package openapi
+
+import (
+	"context"
+	_ "embed"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+)
+
+// Spec documents every route of the router; keep it in sync when adding one.
+//
+//go:embed openapi.json
+var Spec []byte
+
+var schemas = mustLoadSchemas(Spec)
+
+func mustLoadSchemas(spec []byte) openapi3.Schemas {
+	doc, err := openapi3.NewLoader().LoadFromData(spec)
+	if err != nil {
+		panic(fmt.Sprintf("openapi: loading openapi.json: %v", err))
+	}
+	if err = doc.Validate(context.Background()); err != nil {
+		panic(fmt.Sprintf("openapi: invalid openapi.json: %v", err))
+	}
+	return doc.Components.Schemas
+}
+
+// ValidateSchema checks a decoded JSON value against the schema of that name
+// under components/schemas. The error names the offending field.
+func ValidateSchema(name string, value interface{}) error {
+	schema, ok := schemas[name]
+	if !ok || schema.Value == nil {
+		return fmt.Errorf("schema %q is not in openapi.json", name)
+	}
+
+	err := schema.Value.VisitJSON(value)
+	var schemaErr *openapi3.SchemaError
+	if !errors.As(err, &schemaErr) {
+		return err
+	}
+	if pointer := schemaErr.JSONPointer(); len(pointer) > 0 && schemaErr.SchemaField != "required" {
+		return fmt.Errorf("%s: %s", strings.Join(pointer, "."), schemaErr.Reason)
+	}
+	return errors.New(schemaErr.Reason)
+}
@@ -0,0 +1,36 @@
+package openapi
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestValidateSchema(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		body    string
+		wantErr string
+	}{
+		{name: "valid", schema: "Document", body: `{"id": "1", "content": {"string": "alpha"}}`},
+		{name: "missing", schema: "Document", body: `{}`, wantErr: `property "id" is missing`},
+		{name: "nested", schema: "Document", body: `{"id": "1", "stop_words": ["a", 1]}`, wantErr: "stop_words.1: value must be a string"},
+		{name: "unknown field", schema: "Document", body: `{"id": "1", "title": "alpha"}`, wantErr: `property "title" is unsupported`},
+		{name: "unknown schema", schema: "Nothing", body: `{}`, wantErr: `schema "Nothing" is not in openapi.json`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var value interface{}
+			if err := json.Unmarshal([]byte(tt.body), &value); err != nil {
+				t.Fatal(err)
+			}
+			err := ValidateSchema(tt.schema, value)
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("ValidateSchema() = %v, want nil", err)
+			case tt.wantErr != "" && (err == nil || err.Error() != tt.wantErr):
+				t.Errorf("ValidateSchema() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
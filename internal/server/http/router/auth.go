@@ -0,0 +1,78 @@
+package router
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	apiresponse "github.com/ahmadrezamusthafa/search-engine/common/api-response"
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
+	"github.com/gorilla/mux"
+)
+
+type contextKey int
+
+// apiKeyContextKey holds the auth.Key of an authenticated request.
+const apiKeyContextKey contextKey = iota
+
+// requireScope lets a request through when its API key grants the scope and,
+// unless index is nil, may use the index the request names. The key is read
+// from the keyring header, or from the password of basic auth, which
+// browsers prompt for on the web UI. A nil keyring lets every request
+// through.
+func requireScope(keyring *auth.Keyring, scope auth.Scope, index func(r *http.Request) string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if keyring == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secret := r.Header.Get(keyring.Header())
+			if secret == "" {
+				_, secret, _ = r.BasicAuth()
+			}
+
+			key, ok := keyring.Authenticate(secret)
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Basic realm="search-engine"`)
+				respondError(w, http.StatusUnauthorized, fmt.Sprintf("A valid API key is required in the %s header", keyring.Header()))
+				return
+			}
+			if !key.HasScope(scope) {
+				respondError(w, http.StatusForbidden, fmt.Sprintf("API key %q lacks the %s scope", key.Name, scope))
+				return
+			}
+			if index != nil {
+				if name := index(r); !key.CanAccess(name) {
+					respondError(w, http.StatusForbidden, fmt.Sprintf("API key %q may not use index %q", key.Name, name))
+					return
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey, key)))
+		})
+	}
+}
+
+// requestIndex names the index of a request: the {name} route variable or
+// the `index` parameter, and the default index without either.
+func requestIndex(r *http.Request) string {
+	if name := mux.Vars(r)["name"]; name != "" {
+		return name
+	}
+	if name := r.URL.Query().Get("index"); name != "" {
+		return name
+	}
+	return auth.DefaultIndex
+}
+
+// allIndexes is the index of requests on the whole database.
+func allIndexes(*http.Request) string {
+	return auth.AllIndexes
+}
+
+func respondError(w http.ResponseWriter, status int, message string) {
+	response := apiresponse.APIResponse{
+		Status:  "error",
+		Message: message,
+	}
+	apiresponse.RespondJSON(w, status, response)
+}
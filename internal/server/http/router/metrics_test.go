@@ -0,0 +1,71 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/http/handler"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// requestCount reads searchengine_http_requests_total for the labels from
+// the default registry, where the metrics package registers it.
+func requestCount(t *testing.T, route, method, code string) float64 {
+	t.Helper()
+	families, err := prometheus.DefaultGatherer.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, family := range families {
+		if family.GetName() != "searchengine_http_requests_total" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			labels := make(map[string]string)
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["route"] == route && labels["method"] == method && labels["code"] == code {
+				return metric.GetCounter().GetValue()
+			}
+		}
+	}
+	return 0
+}
+
+func TestInstrumentCountsRejections(t *testing.T) {
+	keyring, err := auth.NewKeyring(config.AuthConfig{Keys: []config.APIKeyConfig{
+		{Name: "reader", Hash: auth.Hash("reader-key"), Scopes: []string{"read"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	limits := config.LimitsConfig{PerIP: config.RateLimitConfig{Rate: 0.001, Burst: 2}}
+	r := NewRouter(handler.NewHandler(nil, nil, nil, nil, limits), keyring, limits)
+
+	const route = "/documents/{id}"
+	unauthorized := requestCount(t, route, "GET", "401")
+	tooMany := requestCount(t, route, "GET", "429")
+	for i, target := range []string{"/documents/order-1", "/documents/order-2", "/documents/order-3"} {
+		req := httptest.NewRequest(http.MethodGet, target, nil)
+		req.Header.Set(auth.DefaultHeader, "wrong")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if want := []int{401, 401, 429}[i]; w.Code != want {
+			t.Fatalf("GET %s = %d, want %d", target, w.Code, want)
+		}
+	}
+
+	if got := requestCount(t, route, "GET", "401") - unauthorized; got != 2 {
+		t.Errorf("401 responses counted under %s = %v, want 2", route, got)
+	}
+	if got := requestCount(t, route, "GET", "429") - tooMany; got != 1 {
+		t.Errorf("429 responses counted under %s = %v, want 1", route, got)
+	}
+	if got := requestCount(t, "/documents/order-1", "GET", "401"); got != 0 {
+		t.Errorf("requests counted under the document path = %v, want none", got)
+	}
+}
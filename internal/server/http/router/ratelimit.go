@@ -0,0 +1,56 @@
+package router
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
+	"github.com/ahmadrezamusthafa/search-engine/internal/ratelimit"
+	"github.com/gorilla/mux"
+)
+
+// limitRate rejects requests over the rate of their client with 429 and a
+// Retry-After header. A nil limiter lets every request through.
+func limitRate(limiter *ratelimit.Limiter, client func(r *http.Request) (string, bool)) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		if limiter == nil {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			name, ok := client(r)
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			allowed, retryAfter := limiter.Allow(name, time.Now())
+			if !allowed {
+				seconds := int(math.Ceil(retryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				respondError(w, http.StatusTooManyRequests, fmt.Sprintf("Too many requests, retry in %d seconds", seconds))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientIP is the address the request came from. X-Forwarded-For is not
+// trusted, so behind a proxy every client shares the proxy's bucket.
+func clientIP(r *http.Request) (string, bool) {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr, true
+	}
+	return host, true
+}
+
+// clientKey is the name of the API key the request was authenticated with.
+func clientKey(r *http.Request) (string, bool) {
+	key, ok := r.Context().Value(apiKeyContextKey).(auth.Key)
+	return key.Name, ok
+}
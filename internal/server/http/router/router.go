@@ -2,7 +2,12 @@ package router
 
 import (
 	"embed"
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
+	"github.com/ahmadrezamusthafa/search-engine/internal/metrics"
+	"github.com/ahmadrezamusthafa/search-engine/internal/ratelimit"
 	"github.com/ahmadrezamusthafa/search-engine/internal/server/http/handler"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/http/openapi"
 	"github.com/gorilla/mux"
 	"net/http"
 )
@@ -10,14 +15,67 @@ import (
 //go:embed web/*
 var staticFiles embed.FS
 
-func NewRouter(h *handler.Handler) *mux.Router {
+// NewRouter routes the API behind the API keys of the keyring. Every route
+// needs a key with the scope of its group; a nil keyring leaves them open.
+// Requests are rate limited per client address and per API key, and counted
+// per route in the metrics. The health probes need no key and are not rate
+// limited.
+func NewRouter(h *handler.Handler, keyring *auth.Keyring, limits config.LimitsConfig) *mux.Router {
 	r := mux.NewRouter()
+	r.Use(instrument)
+	r.HandleFunc("/healthz", h.LivenessHandler).Methods("GET")
+	r.HandleFunc("/readyz", h.ReadinessHandler).Methods("GET")
+
+	api := r.NewRoute().Subrouter()
+	api.Use(limitRate(ratelimit.New(limits.PerIP), clientIP))
+	limitKeys := limitRate(ratelimit.New(limits.PerKey), clientKey)
+
+	read := api.NewRoute().Subrouter()
+	read.Use(requireScope(keyring, auth.ScopeRead, requestIndex), limitKeys)
+	write := api.NewRoute().Subrouter()
+	write.Use(requireScope(keyring, auth.ScopeWrite, requestIndex), limitKeys)
+	admin := api.NewRoute().Subrouter()
+	admin.Use(requireScope(keyring, auth.ScopeAdmin, requestIndex), limitKeys)
+	// Backups cover every index, so keys limited to some indexes may not
+	// take or restore them.
+	database := api.NewRoute().Subrouter()
+	database.Use(requireScope(keyring, auth.ScopeAdmin, allIndexes), limitKeys)
+	readAny := api.NewRoute().Subrouter()
+	readAny.Use(requireScope(keyring, auth.ScopeRead, nil), limitKeys)
+	// The metrics cover every index.
+	readAll := api.NewRoute().Subrouter()
+	readAll.Use(requireScope(keyring, auth.ScopeRead, allIndexes), limitKeys)
 
 	staticFS := http.FS(staticFiles)
 	staticHandler := http.FileServer(staticFS)
-	r.PathPrefix("/web").Handler(staticHandler)
+	readAny.PathPrefix("/web").Handler(staticHandler)
+
+	readAny.HandleFunc("/openapi.json", serveOpenAPI).Methods("GET")
+	readAny.HandleFunc("/indexes", h.ListIndexesHandler).Methods("GET")
+	readAll.Handle("/metrics", metrics.Handler()).Methods("GET")
+
+	read.HandleFunc("/search", h.SearchHandler).Methods("GET")
+	read.HandleFunc("/search", h.QueryHandler).Methods("POST")
+	read.HandleFunc("/count", h.CountHandler).Methods("GET")
+	read.HandleFunc("/documents/{id}", h.DocumentHandler).Methods("GET")
+	read.HandleFunc("/documents/{id}", h.DocumentExistsHandler).Methods("HEAD")
+	read.HandleFunc("/documents/{id}/similar", h.SimilarHandler).Methods("GET")
+	read.HandleFunc("/indexes/{name}/search", h.SearchIndexHandler).Methods("GET")
+	read.HandleFunc("/indexes/{name}/search", h.QueryIndexHandler).Methods("POST")
 
-	r.HandleFunc("/index", h.IndexHandler).Methods("POST")
-	r.HandleFunc("/search", h.SearchHandler).Methods("GET")
+	write.HandleFunc("/index", h.IndexHandler).Methods("POST")
+	write.HandleFunc("/indexes/{name}/docs", h.IndexDocsHandler).Methods("POST")
+
+	admin.HandleFunc("/indexes/{name}", h.PutIndexHandler).Methods("PUT")
+	admin.HandleFunc("/admin/export", h.ExportHandler).Methods("GET")
+	admin.HandleFunc("/admin/import", h.ImportHandler).Methods("POST")
+
+	database.HandleFunc("/admin/backup", h.BackupHandler).Methods("GET")
+	database.HandleFunc("/admin/restore", h.RestoreHandler).Methods("POST")
 	return r
 }
+
+func serveOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openapi.Spec)
+}
@@ -0,0 +1,129 @@
+package router
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/ahmadrezamusthafa/search-engine/config"
+	"github.com/ahmadrezamusthafa/search-engine/internal/auth"
+	"github.com/ahmadrezamusthafa/search-engine/internal/engine"
+	"github.com/ahmadrezamusthafa/search-engine/internal/health"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/http/handler"
+	"github.com/ahmadrezamusthafa/search-engine/internal/server/http/openapi"
+	"github.com/ahmadrezamusthafa/search-engine/pkg/badgerdb"
+	"github.com/gorilla/mux"
+)
+
+func TestOpenAPIDocumentsRoutes(t *testing.T) {
+	var spec struct {
+		Paths map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openapi.Spec, &spec); err != nil {
+		t.Fatalf("openapi.json: %v", err)
+	}
+
+	routed := make(map[string]bool)
+	r := NewRouter(handler.NewHandler(nil, nil, nil, nil, config.LimitsConfig{}), nil, config.LimitsConfig{})
+	err := r.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil // a scope group
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil // the static web UI
+		}
+		for _, method := range methods {
+			operation := path + " " + strings.ToLower(method)
+			routed[operation] = true
+			if _, ok := spec.Paths[path][strings.ToLower(method)]; !ok {
+				t.Errorf("%s is not documented", operation)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for path, operations := range spec.Paths {
+		for method := range operations {
+			if !routed[path+" "+method] {
+				t.Errorf("%s %s is documented but not routed", path, method)
+			}
+		}
+	}
+}
+
+func TestAPIKeys(t *testing.T) {
+	keyring, err := auth.NewKeyring(config.AuthConfig{Keys: []config.APIKeyConfig{
+		{Name: "reader", Hash: auth.Hash("reader-key"), Scopes: []string{"read"}, Indexes: []string{"transfers"}},
+		{Name: "admin", Hash: auth.Hash("admin-key"), Scopes: []string{"admin"}, Indexes: []string{"transfers"}},
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg := &config.Config{BM25: config.BM25Config{K1: 1.2, B: 0.75}}
+	db := badgerdb.NewBadgerDB(config.BadgerConfig{Path: t.TempDir()})
+	t.Cleanup(func() { db.Close() })
+	se, err := engine.NewSearchEngine(engine.PersistenceBadger, cfg, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { se.Close() })
+	indexes, err := engine.NewIndexRegistry(engine.PersistenceBadger, cfg, db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { indexes.Close() })
+	if _, err = indexes.PutIndex("transfers", indexes.DefaultSettings("transfers")); err != nil {
+		t.Fatal(err)
+	}
+	r := NewRouter(handler.NewHandler(se, indexes, nil, health.NewChecker(0), config.LimitsConfig{}), keyring, config.LimitsConfig{})
+
+	tests := []struct {
+		method, target, body, key string
+		want                      int
+	}{
+		{method: "GET", target: "/openapi.json", want: http.StatusUnauthorized},
+		{method: "GET", target: "/web/", key: "wrong", want: http.StatusUnauthorized},
+		{method: "GET", target: "/openapi.json", key: "reader-key", want: http.StatusOK},
+		{method: "GET", target: "/web/", key: "reader-key", want: http.StatusOK},
+		{method: "GET", target: "/search?query=a", key: "reader-key", want: http.StatusForbidden},
+		{method: "GET", target: "/count?query=a&index=other", key: "reader-key", want: http.StatusForbidden},
+		{method: "GET", target: "/search?query=a&index=transfers", key: "reader-key", want: http.StatusOK},
+		{method: "POST", target: "/search?index=transfers", body: `{"query": {"match": {"remark": "a"}}}`, key: "reader-key", want: http.StatusOK},
+		{method: "POST", target: "/index?index=transfers", body: `{"id": "1", "content": {"object": {"name": "a"}, "object_indexes": ["name"]}}`, key: "admin-key", want: http.StatusOK},
+		{method: "POST", target: "/indexes/transfers/docs", key: "reader-key", want: http.StatusForbidden},
+		{method: "PUT", target: "/indexes/other", key: "admin-key", want: http.StatusForbidden},
+		{method: "GET", target: "/admin/backup", key: "admin-key", want: http.StatusForbidden},
+		{method: "GET", target: "/metrics", key: "reader-key", want: http.StatusForbidden},
+		{method: "GET", target: "/healthz", want: http.StatusOK},
+		{method: "GET", target: "/readyz", want: http.StatusOK},
+	}
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+		if tt.key != "" {
+			req.Header.Set(auth.DefaultHeader, tt.key)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.want {
+			t.Errorf("%s %s with %q = %d, want %d: %s", tt.method, tt.target, tt.key, w.Code, tt.want, w.Body)
+		}
+	}
+
+	// The index parameter picked the engine the key was checked against:
+	// the document went to transfers, not to the default index.
+	transfers, _ := indexes.GetIndex("transfers")
+	if exists, _ := transfers.DocumentExists(context.Background(), "1"); !exists {
+		t.Error("POST /index?index=transfers did not store into transfers")
+	}
+	if exists, _ := se.DocumentExists(context.Background(), "1"); exists {
+		t.Error("POST /index?index=transfers stored into the default index")
+	}
+}
@@ -86,8 +86,8 @@ function submitIndex() {
         id: dynamicId, content: {
             string: contentString,
             object: JSON.parse(contentObject),
-            object_indexes: objectIndexes.map(item => item.trim())
-        }, stop_words: stopWords.map(word => word.trim())
+            object_indexes: objectIndexes.map(item => item.trim()).filter(item => item)
+        }, stop_words: stopWords.map(word => word.trim()).filter(word => word)
     };
 
     fetch('/index', {
@@ -1,7 +1,19 @@
 package structs
 
+import "fmt"
+
 type Content struct {
 	String        string                 `json:"string"`
 	Object        map[string]interface{} `json:"object"`
 	ObjectIndexes []string               `json:"object_indexes"`
 }
+
+// Validate reports object indexes naming fields the object does not have.
+func (c Content) Validate() error {
+	for _, field := range c.ObjectIndexes {
+		if _, ok := c.Object[field]; !ok {
+			return fmt.Errorf("field %q in object_indexes does not exist in object", field)
+		}
+	}
+	return nil
+}
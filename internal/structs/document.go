@@ -1,7 +1,20 @@
 package structs
 
+import "errors"
+
 type Document struct {
 	ID        string   `json:"id"`
 	Content   Content  `json:"content"`
 	StopWords []string `json:"stop_words"`
+	// Vector is an optional dense embedding for kNN search. Every vector of
+	// an index must have the same dimension.
+	Vector []float32 `json:"vector,omitempty"`
+}
+
+// Validate reports a missing id and invalid content.
+func (d Document) Validate() error {
+	if d.ID == "" {
+		return errors.New("id is required")
+	}
+	return d.Content.Validate()
 }
@@ -0,0 +1,9 @@
+package structs
+
+// Explanation is one node of a score breakdown. Value is the number the node
+// contributes and Details the inputs it was computed from.
+type Explanation struct {
+	Value       float64       `json:"value"`
+	Description string        `json:"description"`
+	Details     []Explanation `json:"details,omitempty"`
+}
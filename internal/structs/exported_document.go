@@ -0,0 +1,21 @@
+package structs
+
+// ExportedDocument is the backend-neutral form of a stored document, enough
+// to rebuild its postings and statistics in any search engine.
+type ExportedDocument struct {
+	ID      string    `json:"id"`
+	Tokens  []string  `json:"tokens"`
+	Content *Content  `json:"content,omitempty"`
+	Vector  []float32 `json:"vector,omitempty"`
+	// ExpiresAt is when the document expires, in unix seconds. Exports of
+	// older versions leave it zero.
+	ExpiresAt int64 `json:"expires_at,omitempty"`
+}
+
+// Contents returns the content of the document as the store methods take it.
+func (d ExportedDocument) Contents() []Content {
+	if d.Content == nil {
+		return nil
+	}
+	return []Content{*d.Content}
+}
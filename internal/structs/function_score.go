@@ -0,0 +1,47 @@
+package structs
+
+// FunctionScore adjusts the query score of every hit with functions of the
+// document's object fields.
+type FunctionScore struct {
+	Functions []ScoreFunction `json:"functions"`
+	// ScoreMode combines the function results: multiply (default), sum, avg,
+	// first, max or min.
+	ScoreMode string `json:"score_mode,omitempty"`
+	// BoostMode combines the query score with the combined function result:
+	// multiply (default), replace, sum, avg, max or min.
+	BoostMode string `json:"boost_mode,omitempty"`
+}
+
+// ScoreFunction sets one of the function kinds. Weight multiplies its result,
+// or is the result on its own when no function kind is set.
+type ScoreFunction struct {
+	FieldValueFactor *FieldValueFactor `json:"field_value_factor,omitempty"`
+	Gauss            *DecayFunction    `json:"gauss,omitempty"`
+	Exp              *DecayFunction    `json:"exp,omitempty"`
+	Linear           *DecayFunction    `json:"linear,omitempty"`
+	Weight           float64           `json:"weight,omitempty"`
+}
+
+// FieldValueFactor scores a hit by modifier(factor * value) of a numeric
+// field.
+type FieldValueFactor struct {
+	Field  string  `json:"field"`
+	Factor float64 `json:"factor,omitempty"`
+	// Modifier is none (default), log, log1p, log2p, ln, ln1p, ln2p, sqrt,
+	// square or reciprocal.
+	Modifier string `json:"modifier,omitempty"`
+	// Missing is used when the document has no numeric value for the field.
+	Missing *float64 `json:"missing,omitempty"`
+}
+
+// DecayFunction scores a hit by the distance of a numeric or date field from
+// Origin: 1 within Offset, and Decay at Offset + Scale. Origin accepts a
+// number, an RFC 3339 date or "now"; Scale and Offset accept a number or a
+// duration such as "7d" or "12h" for date fields stored as unix seconds.
+type DecayFunction struct {
+	Field  string      `json:"field"`
+	Origin interface{} `json:"origin"`
+	Scale  interface{} `json:"scale"`
+	Offset interface{} `json:"offset,omitempty"`
+	Decay  float64     `json:"decay,omitempty"`
+}
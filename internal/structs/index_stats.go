@@ -0,0 +1,8 @@
+package structs
+
+// IndexStats are the collection statistics of an index.
+type IndexStats struct {
+	DocCount int `json:"doc_count"`
+	// TokenCount is the number of tokens of all stored documents.
+	TokenCount int `json:"token_count"`
+}
@@ -0,0 +1,14 @@
+package structs
+
+// Knn finds the K documents whose vectors are most similar to Vector by
+// cosine similarity.
+type Knn struct {
+	Vector []float32 `json:"vector"`
+	K      int       `json:"k,omitempty"`
+	// NumCandidates is the HNSW candidate list size; larger values trade
+	// speed for recall.
+	NumCandidates int `json:"num_candidates,omitempty"`
+	// RankConstant dampens the influence of the top ranks in reciprocal rank
+	// fusion: each list adds 1 / (RankConstant + rank).
+	RankConstant int `json:"rank_constant,omitempty"`
+}
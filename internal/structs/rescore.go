@@ -0,0 +1,32 @@
+package structs
+
+// Rescore re-ranks the top WindowSize hits with a more expensive scorer. Set
+// exactly one of Phrase, Exact or Function.
+type Rescore struct {
+	WindowSize int `json:"window_size,omitempty"`
+	// QueryWeight and RescoreWeight scale the original and the rescore score
+	// before they are combined. Both default to 1.
+	QueryWeight   *float64 `json:"query_weight,omitempty"`
+	RescoreWeight *float64 `json:"rescore_weight,omitempty"`
+	// ScoreMode is total (default), multiply, avg, max or min.
+	ScoreMode string `json:"score_mode,omitempty"`
+
+	Phrase *PhraseRescore `json:"phrase,omitempty"`
+	Exact  *ExactRescore  `json:"exact,omitempty"`
+	// Function names a scorer registered with engine.RegisterRescorer.
+	Function string `json:"function,omitempty"`
+}
+
+// PhraseRescore scores how close together the query terms appear within one
+// field, from 0 to 1. Slop is the number of extra tokens a match may span
+// and still count as a phrase.
+type PhraseRescore struct {
+	Slop int `json:"slop,omitempty"`
+}
+
+// ExactRescore scores 1 when an object field equals Value, or any query term
+// when Value is omitted, and 0 otherwise.
+type ExactRescore struct {
+	Field string      `json:"field,omitempty"`
+	Value interface{} `json:"value,omitempty"`
+}
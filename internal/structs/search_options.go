@@ -0,0 +1,34 @@
+package structs
+
+type SearchOptions struct {
+	// Fields restricts matching to the listed fields, each with a boost, and
+	// scores them with BM25F.
+	Fields map[string]float64 `json:"fields,omitempty"`
+	// MinimumShouldMatch is the number of distinct query terms a hit must
+	// match: a count such as "3" or "-1", or a percentage such as "75%".
+	MinimumShouldMatch string `json:"minimum_should_match,omitempty"`
+	// MinScore drops hits scoring below it, before rescoring and paging.
+	MinScore float64 `json:"min_score,omitempty"`
+	// Collapse keeps only the best hit per value of this object field.
+	Collapse string `json:"collapse,omitempty"`
+	// From and Size page through the hits, or the groups when collapsing.
+	// A zero Size returns the default number of hits.
+	From int `json:"from,omitempty"`
+	Size int `json:"size,omitempty"`
+	// Knn adds nearest neighbours of a vector. Together with query terms the
+	// keyword and kNN hits are fused by reciprocal rank.
+	Knn *Knn `json:"knn,omitempty"`
+	// Explain attaches the score breakdown of every hit.
+	Explain bool `json:"explain,omitempty"`
+	// FunctionScore adjusts the query scores with the document's fields.
+	FunctionScore *FunctionScore `json:"function_score,omitempty"`
+	// Rescore re-ranks the best hits in a second phase.
+	Rescore *Rescore `json:"rescore,omitempty"`
+	// Sort orders the hits by object fields instead of by score.
+	Sort []SortField `json:"sort,omitempty"`
+	// Source selects the stored data returned with every hit.
+	Source *SourceFilter `json:"_source,omitempty"`
+	// Timeout bounds the time spent reading postings, e.g. "50ms". When it
+	// expires the search returns the hits found so far.
+	Timeout string `json:"timeout,omitempty"`
+}
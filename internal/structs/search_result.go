@@ -1,7 +1,25 @@
 package structs
 
 type SearchResult struct {
-	ID    string      `json:"id"`
-	Score float64     `json:"score,omitempty"`
-	Data  interface{} `json:"data"`
+	ID          string       `json:"id"`
+	Score       float64      `json:"score,omitempty"`
+	Data        interface{}  `json:"data"`
+	InnerHits   *InnerHits   `json:"inner_hits,omitempty"`
+	Explanation *Explanation `json:"explanation,omitempty"`
+}
+
+// InnerHits describes the group a collapsed hit stands for. Total counts the
+// hit itself.
+type InnerHits struct {
+	Field string      `json:"field"`
+	Value interface{} `json:"value"`
+	Total int         `json:"total"`
+}
+
+// SearchResponse holds the hits of a search. TimedOut is set when the search
+// timeout expired before every posting was read; the hits are then those
+// found until then.
+type SearchResponse struct {
+	Hits     []SearchResult `json:"hits"`
+	TimedOut bool           `json:"timed_out"`
 }
@@ -0,0 +1,107 @@
+package structs
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ScoreField sorts by the hit score.
+const ScoreField = "_score"
+
+const (
+	SortAsc  = "asc"
+	SortDesc = "desc"
+)
+
+// SortField is one sort key. In JSON it is a field name, {"<field>": "desc"}
+// or {"<field>": {"order": "desc"}}. Fields sort ascending and _score
+// descending unless Order says otherwise.
+type SortField struct {
+	Field string `json:"field"`
+	Order string `json:"order,omitempty"`
+}
+
+// Descending reports whether the key sorts from high to low.
+func (f SortField) Descending() bool {
+	if f.Order == "" {
+		return f.Field == ScoreField
+	}
+	return f.Order == SortDesc
+}
+
+func (f *SortField) UnmarshalJSON(data []byte) error {
+	var field string
+	if err := json.Unmarshal(data, &field); err == nil {
+		*f = SortField{Field: field}
+		return f.Validate()
+	}
+
+	var keyed map[string]json.RawMessage
+	if err := json.Unmarshal(data, &keyed); err != nil || len(keyed) != 1 {
+		return errors.New(`sort entries must be a field name or {"<field>": "asc" | "desc"}`)
+	}
+	for field, order := range keyed {
+		*f = SortField{Field: field}
+		if err := json.Unmarshal(order, &f.Order); err != nil {
+			var options struct {
+				Order string `json:"order"`
+			}
+			if err = json.Unmarshal(order, &options); err != nil {
+				return fmt.Errorf("invalid sort order of %q", field)
+			}
+			f.Order = options.Order
+		}
+	}
+	f.Order = strings.ToLower(f.Order)
+	return f.Validate()
+}
+
+// Validate reports an empty field or an unknown order.
+func (f SortField) Validate() error {
+	if f.Field == "" {
+		return errors.New("sort field must not be empty")
+	}
+	if f.Order != "" && f.Order != SortAsc && f.Order != SortDesc {
+		return fmt.Errorf("invalid sort order %q of %q: use asc or desc", f.Order, f.Field)
+	}
+	return nil
+}
+
+// SourceFilter selects the returned data. In JSON it is false to leave the
+// data out, true to return all of it, or a list of object fields. The
+// string content is returned when the list names "string".
+type SourceFilter struct {
+	Disabled bool     `json:"disabled,omitempty"`
+	Includes []string `json:"includes,omitempty"`
+}
+
+func (f *SourceFilter) UnmarshalJSON(data []byte) error {
+	var enabled bool
+	if err := json.Unmarshal(data, &enabled); err == nil {
+		*f = SourceFilter{Disabled: !enabled}
+		return nil
+	}
+
+	var includes []string
+	if err := json.Unmarshal(data, &includes); err == nil {
+		*f = SourceFilter{Includes: includes}
+		return nil
+	}
+
+	var field string
+	if err := json.Unmarshal(data, &field); err == nil {
+		*f = SourceFilter{Includes: []string{field}}
+		return nil
+	}
+
+	var options struct {
+		Includes []string `json:"includes"`
+	}
+	if err := json.Unmarshal(data, &options); err != nil {
+		return errors.New("_source must be true, false, a field name or a list of fields")
+	}
+	*f = SourceFilter{Includes: options.Includes}
+	return nil
+}
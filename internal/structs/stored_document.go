@@ -0,0 +1,11 @@
+package structs
+
+// StoredDocument is a document as the index holds it.
+type StoredDocument struct {
+	ID   string      `json:"id"`
+	Data interface{} `json:"data"`
+	// TokenLength is the number of tokens indexed for the document.
+	TokenLength int `json:"token_length"`
+	// TTL is the time to live left, in seconds.
+	TTL int64 `json:"ttl"`
+}
@@ -1,16 +1,20 @@
 package badgerdb
 
 import (
+	"bytes"
 	"encoding/binary"
 	"encoding/json"
 	"errors"
 	"github.com/ahmadrezamusthafa/search-engine/config"
 	"github.com/dgraph-io/badger/v4"
 	"github.com/dgraph-io/badger/v4/options"
+	"io"
 	"log"
 	"time"
 )
 
+const maxPendingRestoreWrites = 256
+
 type BadgerDB struct {
 	DB *badger.DB
 }
@@ -53,22 +57,14 @@ func NewBadgerDB(cfg config.BadgerConfig) *BadgerDB {
 
 func (b *BadgerDB) SetInt(key string, value int, ttl time.Duration) error {
 	return b.DB.Update(func(txn *badger.Txn) error {
-		valueBytes := make([]byte, 8)
-		binary.BigEndian.PutUint64(valueBytes, uint64(value))
-
-		entry := badger.NewEntry([]byte(key), valueBytes).WithTTL(ttl)
-		return txn.SetEntry(entry)
+		return SetInt(txn, key, value, ttl)
 	})
 }
 
 func (b *BadgerDB) SetIntegers(ttl time.Duration, kvIntegers ...KVInt) error {
 	return b.DB.Update(func(txn *badger.Txn) error {
 		for _, kvInt := range kvIntegers {
-			valueBytes := make([]byte, 8)
-			binary.BigEndian.PutUint64(valueBytes, uint64(kvInt.Value))
-
-			entry := badger.NewEntry([]byte(kvInt.Key), valueBytes).WithTTL(ttl)
-			err := txn.SetEntry(entry)
+			err := SetInt(txn, kvInt.Key, kvInt.Value, ttl)
 			if err != nil {
 				return err
 			}
@@ -80,60 +76,177 @@ func (b *BadgerDB) SetIntegers(ttl time.Duration, kvIntegers ...KVInt) error {
 func (b *BadgerDB) GetInt(key string) (int, error) {
 	var intValue int
 	err := b.DB.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(key))
-		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
-			return err
-		}
-		if item != nil {
-			val, err := item.ValueCopy(nil)
-			if err != nil {
-				return err
-			}
-			intValue = int(binary.BigEndian.Uint64(val))
-		}
-		return nil
+		var err error
+		intValue, err = GetInt(txn, key)
+		return err
 	})
 	return intValue, err
 }
 
 func (b *BadgerDB) SetObject(key string, value interface{}, ttl time.Duration) error {
 	return b.DB.Update(func(txn *badger.Txn) error {
-		valueBytes, err := json.Marshal(value)
-		if err != nil {
-			return err
-		}
-
-		entry := badger.NewEntry([]byte(key), valueBytes).WithTTL(ttl)
-		return txn.SetEntry(entry)
+		return SetObject(txn, key, value, ttl)
 	})
 }
 
 func (b *BadgerDB) GetObject(key string, target interface{}) error {
 	return b.DB.View(func(txn *badger.Txn) error {
-		item, err := txn.Get([]byte(key))
-		if err != nil && !errors.Is(err, badger.ErrKeyNotFound) {
-			return err
+		return GetObject(txn, key, target)
+	})
+}
+
+// SetInt sets key to value inside txn.
+func SetInt(txn *badger.Txn, key string, value int, ttl time.Duration) error {
+	valueBytes := make([]byte, 8)
+	binary.BigEndian.PutUint64(valueBytes, uint64(value))
+
+	return txn.SetEntry(newEntry(key, valueBytes, ttl))
+}
+
+// GetInt reads key inside txn, seeing the writes of txn. A missing key reads
+// as zero.
+func GetInt(txn *badger.Txn, key string) (int, error) {
+	item, err := txn.Get([]byte(key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return 0, err
+	}
+	return int(binary.BigEndian.Uint64(val)), nil
+}
+
+// SetObject sets key to the JSON encoding of value inside txn.
+func SetObject(txn *badger.Txn, key string, value interface{}, ttl time.Duration) error {
+	valueBytes, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+
+	return txn.SetEntry(newEntry(key, valueBytes, ttl))
+}
+
+// GetObject decodes key into target inside txn, seeing the writes of txn. A
+// missing key leaves target untouched.
+func GetObject(txn *badger.Txn, key string, target interface{}) error {
+	item, err := txn.Get([]byte(key))
+	if errors.Is(err, badger.ErrKeyNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	val, err := item.ValueCopy(nil)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(val, target)
+}
+
+func (b *BadgerDB) DeleteKey(key string) error {
+	return b.DB.Update(func(txn *badger.Txn) error {
+		return txn.Delete([]byte(key))
+	})
+}
+
+func (b *BadgerDB) DeleteKeys(keys ...string) error {
+	return b.DB.Update(func(txn *badger.Txn) error {
+		for _, key := range keys {
+			err := txn.Delete([]byte(key))
+			if err != nil {
+				return err
+			}
 		}
-		if item != nil {
+		return nil
+	})
+}
+
+// IteratePrefix walks every live key starting with prefix in key order and
+// stops as soon as fn returns false.
+func (b *BadgerDB) IteratePrefix(prefix string, fn func(key string, value []byte) bool) error {
+	return b.DB.View(func(txn *badger.Txn) error {
+		it := txn.NewIterator(badger.DefaultIteratorOptions)
+		defer it.Close()
+
+		prefixBytes := []byte(prefix)
+		for it.Seek(prefixBytes); it.ValidForPrefix(prefixBytes); it.Next() {
+			item := it.Item()
 			val, err := item.ValueCopy(nil)
 			if err != nil {
 				return err
 			}
-			return json.Unmarshal(val, target)
+			if !fn(string(item.KeyCopy(nil)), val) {
+				return nil
+			}
 		}
 		return nil
 	})
 }
 
-func (b *BadgerDB) DeleteKey(key string) error {
+// Backup streams a consistent snapshot of every entry newer than since into w
+// and returns the version to pass as since for the next incremental backup.
+// Badger streams the entries with a version above since, so that is the
+// version of the last entry, or since again when nothing was newer. The
+// keys of health probes are left out.
+func (b *BadgerDB) Backup(w io.Writer, since uint64) (uint64, error) {
+	stream := b.DB.NewStream()
+	stream.LogPrefix = "DB.Backup"
+	stream.SinceTs = since
+	stream.ChooseKey = func(item *badger.Item) bool {
+		return !bytes.HasPrefix(item.Key(), []byte(probePrefix))
+	}
+	version, err := stream.Backup(w, since)
+	if err != nil {
+		return 0, err
+	}
+	return max(version, since), nil
+}
+
+// DropAll deletes every key, for a restore that replaces the database.
+func (b *BadgerDB) DropAll() error {
+	return b.DB.DropAll()
+}
+
+// Restore loads a snapshot produced by Backup. Backups are applied in the
+// order they were taken, starting from the full one.
+func (b *BadgerDB) Restore(r io.Reader) error {
+	return b.DB.Load(r, maxPendingRestoreWrites)
+}
+
+// newEntry builds an entry that expires after ttl, or never when ttl is zero.
+func newEntry(key string, value []byte, ttl time.Duration) *badger.Entry {
+	entry := badger.NewEntry([]byte(key), value)
+	if ttl > 0 {
+		entry = entry.WithTTL(ttl)
+	}
+	return entry
+}
+
+// probePrefix holds the keys of CheckWritable. Backups skip it, so probes
+// never end up in a snapshot.
+const probePrefix = "health:"
+
+// CheckWritable commits a transaction that sets and deletes a reserved
+// probe key, so a database that is open but rejects writes is reported.
+func (b *BadgerDB) CheckWritable() error {
+	if b.DB.IsClosed() {
+		return errors.New("database is closed")
+	}
+	key := []byte(probePrefix + "probe")
+	value := []byte(time.Now().UTC().Format(time.RFC3339Nano))
 	return b.DB.Update(func(txn *badger.Txn) error {
-		return txn.Delete([]byte(key))
+		if err := txn.SetEntry(badger.NewEntry(key, value).WithTTL(time.Minute)); err != nil {
+			return err
+		}
+		return txn.Delete(key)
 	})
 }
 
-func (b *BadgerDB) Close() {
-	err := b.DB.Close()
-	if err != nil {
-		log.Fatal(err)
-	}
+// Close flushes the memtables and closes the database files.
+func (b *BadgerDB) Close() error {
+	return b.DB.Close()
 }
@@ -0,0 +1,361 @@
+// Package hnsw is an in-memory Hierarchical Navigable Small World graph for
+// approximate nearest neighbour search by cosine similarity.
+package hnsw
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+)
+
+const (
+	DefaultM              = 16
+	DefaultEfConstruction = 200
+)
+
+var ErrZeroVector = errors.New("vector must not be all zeros")
+
+// Result is one neighbour with its cosine similarity to the query.
+type Result struct {
+	ID         string
+	Similarity float64
+}
+
+type node struct {
+	id      string
+	vector  []float32
+	friends [][]int
+	deleted bool
+}
+
+// Index is safe for concurrent use. Removed vectors stay in the graph as
+// tombstones to keep it navigable, and the graph is rebuilt once they
+// outnumber the live vectors.
+type Index struct {
+	mu             sync.RWMutex
+	m              int
+	mMax0          int
+	efConstruction int
+	levelMult      float64
+	dims           int
+	nodes          []*node
+	ids            map[string]int
+	entry          int
+	maxLevel       int
+	deleted        int
+	rng            *rand.Rand
+}
+
+// New creates an empty index. m is the number of neighbours per node and
+// layer, efConstruction the candidate list size while inserting; zero
+// selects the defaults.
+func New(m, efConstruction int) *Index {
+	if m <= 0 {
+		m = DefaultM
+	}
+	if efConstruction <= 0 {
+		efConstruction = DefaultEfConstruction
+	}
+	return &Index{
+		m:              m,
+		mMax0:          2 * m,
+		efConstruction: efConstruction,
+		levelMult:      1 / math.Log(float64(m)),
+		ids:            make(map[string]int),
+		entry:          -1,
+		rng:            rand.New(rand.NewSource(1)),
+	}
+}
+
+// Dims returns the vector dimension, fixed by the first vector added, or 0
+// while the index has never held a vector.
+func (idx *Index) Dims() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return idx.dims
+}
+
+// Len returns the number of live vectors.
+func (idx *Index) Len() int {
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	return len(idx.ids)
+}
+
+// Add inserts the vector of id, replacing a previous one.
+func (idx *Index) Add(id string, vector []float32) error {
+	normalized, err := normalize(vector)
+	if err != nil {
+		return err
+	}
+
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	if idx.dims != 0 && len(vector) != idx.dims {
+		return fmt.Errorf("vector has %d dimensions, index has %d", len(vector), idx.dims)
+	}
+	idx.dims = len(vector)
+
+	idx.remove(id)
+	idx.insert(id, normalized)
+	idx.compact()
+	return nil
+}
+
+// Remove deletes the vector of id, if any.
+func (idx *Index) Remove(id string) {
+	idx.mu.Lock()
+	defer idx.mu.Unlock()
+
+	idx.remove(id)
+	idx.compact()
+}
+
+// Search returns up to k live neighbours of query, most similar first. ef is
+// the candidate list size; larger values trade speed for recall.
+func (idx *Index) Search(query []float32, k, ef int) ([]Result, error) {
+	normalized, err := normalize(query)
+	if err != nil {
+		return nil, err
+	}
+
+	idx.mu.RLock()
+	defer idx.mu.RUnlock()
+
+	if idx.entry < 0 {
+		return nil, nil
+	}
+	if len(query) != idx.dims {
+		return nil, fmt.Errorf("query vector has %d dimensions, index has %d", len(query), idx.dims)
+	}
+	if ef < k {
+		ef = k
+	}
+
+	entry := idx.entry
+	for level := idx.maxLevel; level > 0; level-- {
+		entry = idx.searchLayer(normalized, []int{entry}, 1, level)[0].node
+	}
+	for {
+		results := make([]Result, 0, k)
+		for _, candidate := range idx.searchLayer(normalized, []int{entry}, ef, 0) {
+			n := idx.nodes[candidate.node]
+			if n.deleted {
+				continue
+			}
+			results = append(results, Result{ID: n.id, Similarity: 1 - candidate.distance})
+			if len(results) == k {
+				break
+			}
+		}
+		// Tombstones take up candidate slots; widen the search if they
+		// crowded out live vectors.
+		if len(results) == k || len(results) == len(idx.ids) || ef >= len(idx.nodes) {
+			return results, nil
+		}
+		ef *= 2
+	}
+}
+
+func (idx *Index) insert(id string, vector []float32) {
+	level := int(math.Floor(-math.Log(1-idx.rng.Float64()) * idx.levelMult))
+	n := &node{id: id, vector: vector, friends: make([][]int, level+1)}
+	position := len(idx.nodes)
+	idx.nodes = append(idx.nodes, n)
+	idx.ids[id] = position
+
+	if idx.entry < 0 {
+		idx.entry, idx.maxLevel = position, level
+		return
+	}
+
+	entry := idx.entry
+	for l := idx.maxLevel; l > level; l-- {
+		entry = idx.searchLayer(vector, []int{entry}, 1, l)[0].node
+	}
+
+	entries := []int{entry}
+	for l := min(level, idx.maxLevel); l >= 0; l-- {
+		candidates := idx.searchLayer(vector, entries, idx.efConstruction, l)
+		maxFriends := idx.m
+		if l == 0 {
+			maxFriends = idx.mMax0
+		}
+
+		neighbours := candidates[:min(len(candidates), idx.m)]
+		for _, neighbour := range neighbours {
+			n.friends[l] = append(n.friends[l], neighbour.node)
+			friend := idx.nodes[neighbour.node]
+			friend.friends[l] = append(friend.friends[l], position)
+			if len(friend.friends[l]) > maxFriends {
+				friend.friends[l] = idx.closest(friend.vector, friend.friends[l], maxFriends)
+			}
+		}
+
+		entries = entries[:0]
+		for _, candidate := range candidates {
+			entries = append(entries, candidate.node)
+		}
+	}
+
+	if level > idx.maxLevel {
+		idx.entry, idx.maxLevel = position, level
+	}
+}
+
+func (idx *Index) remove(id string) {
+	position, ok := idx.ids[id]
+	if !ok {
+		return
+	}
+	idx.nodes[position].deleted = true
+	delete(idx.ids, id)
+	idx.deleted++
+}
+
+// compact rebuilds the graph from the live vectors once tombstones outnumber
+// them.
+func (idx *Index) compact() {
+	if idx.deleted <= len(idx.ids) {
+		return
+	}
+
+	nodes := idx.nodes
+	idx.nodes = nil
+	idx.ids = make(map[string]int)
+	idx.entry, idx.maxLevel, idx.deleted = -1, 0, 0
+	for _, n := range nodes {
+		if !n.deleted {
+			idx.insert(n.id, n.vector)
+		}
+	}
+	if len(idx.ids) == 0 {
+		idx.dims = 0
+	}
+}
+
+// closest keeps the n positions nearest to vector.
+func (idx *Index) closest(vector []float32, positions []int, n int) []int {
+	sort.Slice(positions, func(i, j int) bool {
+		return distance(vector, idx.nodes[positions[i]].vector) < distance(vector, idx.nodes[positions[j]].vector)
+	})
+	return positions[:n]
+}
+
+type candidate struct {
+	node     int
+	distance float64
+}
+
+// searchLayer returns up to ef nodes of one layer nearest to query, nearest
+// first.
+func (idx *Index) searchLayer(query []float32, entries []int, ef, level int) []candidate {
+	visited := make(map[int]bool, ef*4)
+	candidates := &minHeap{}
+	results := &maxHeap{}
+	for _, entry := range entries {
+		visited[entry] = true
+		c := candidate{node: entry, distance: distance(query, idx.nodes[entry].vector)}
+		heap.Push(candidates, c)
+		heap.Push(results, c)
+		if results.Len() > ef {
+			heap.Pop(results)
+		}
+	}
+
+	for candidates.Len() > 0 {
+		current := heap.Pop(candidates).(candidate)
+		if results.Len() >= ef && current.distance > (*results)[0].distance {
+			break
+		}
+
+		friends := idx.nodes[current.node].friends
+		if level >= len(friends) {
+			continue
+		}
+		for _, friend := range friends[level] {
+			if visited[friend] {
+				continue
+			}
+			visited[friend] = true
+
+			c := candidate{node: friend, distance: distance(query, idx.nodes[friend].vector)}
+			if results.Len() < ef || c.distance < (*results)[0].distance {
+				heap.Push(candidates, c)
+				heap.Push(results, c)
+				if results.Len() > ef {
+					heap.Pop(results)
+				}
+			}
+		}
+	}
+
+	nearest := make([]candidate, results.Len())
+	for i := len(nearest) - 1; i >= 0; i-- {
+		nearest[i] = heap.Pop(results).(candidate)
+	}
+	return nearest
+}
+
+func normalize(vector []float32) ([]float32, error) {
+	if len(vector) == 0 {
+		return nil, errors.New("vector must not be empty")
+	}
+
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	if norm == 0 {
+		return nil, ErrZeroVector
+	}
+
+	norm = math.Sqrt(norm)
+	normalized := make([]float32, len(vector))
+	for i, v := range vector {
+		normalized[i] = float32(float64(v) / norm)
+	}
+	return normalized, nil
+}
+
+// distance is the cosine distance of two normalized vectors.
+func distance(a, b []float32) float64 {
+	var dot float64
+	for i := range a {
+		dot += float64(a[i]) * float64(b[i])
+	}
+	return 1 - dot
+}
+
+type minHeap []candidate
+
+func (h minHeap) Len() int            { return len(h) }
+func (h minHeap) Less(i, j int) bool  { return h[i].distance < h[j].distance }
+func (h minHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *minHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *minHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
+
+type maxHeap []candidate
+
+func (h maxHeap) Len() int            { return len(h) }
+func (h maxHeap) Less(i, j int) bool  { return h[i].distance > h[j].distance }
+func (h maxHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *maxHeap) Push(x interface{}) { *h = append(*h, x.(candidate)) }
+func (h *maxHeap) Pop() interface{} {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
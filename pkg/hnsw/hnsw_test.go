@@ -0,0 +1,159 @@
+package hnsw
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"testing"
+)
+
+func randomVectors(n, dims int, seed int64) map[string][]float32 {
+	rng := rand.New(rand.NewSource(seed))
+	vectors := make(map[string][]float32, n)
+	for i := 0; i < n; i++ {
+		vector := make([]float32, dims)
+		for j := range vector {
+			vector[j] = rng.Float32()*2 - 1
+		}
+		vectors[fmt.Sprintf("doc:%d", i)] = vector
+	}
+	return vectors
+}
+
+func bruteForce(vectors map[string][]float32, query []float32, k int) []string {
+	q, _ := normalize(query)
+	type scored struct {
+		id       string
+		distance float64
+	}
+	all := make([]scored, 0, len(vectors))
+	for id, vector := range vectors {
+		v, _ := normalize(vector)
+		all = append(all, scored{id: id, distance: distance(q, v)})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].distance < all[j].distance })
+
+	ids := make([]string, 0, k)
+	for _, s := range all[:k] {
+		ids = append(ids, s.id)
+	}
+	return ids
+}
+
+func recall(t *testing.T, idx *Index, vectors map[string][]float32, queries [][]float32, k int) float64 {
+	t.Helper()
+
+	found, total := 0, 0
+	for _, query := range queries {
+		results, err := idx.Search(query, k, 64)
+		if err != nil {
+			t.Fatalf("Search() error = %v", err)
+		}
+		want := make(map[string]bool)
+		for _, id := range bruteForce(vectors, query, k) {
+			want[id] = true
+		}
+		for _, result := range results {
+			if want[result.ID] {
+				found++
+			}
+		}
+		total += k
+	}
+	return float64(found) / float64(total)
+}
+
+func TestIndexSearchRecall(t *testing.T) {
+	vectors := randomVectors(1000, 16, 1)
+	idx := New(0, 0)
+	for id, vector := range vectors {
+		if err := idx.Add(id, vector); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	queries := make([][]float32, 0, 20)
+	for _, vector := range randomVectors(20, 16, 2) {
+		queries = append(queries, vector)
+	}
+
+	if got := recall(t, idx, vectors, queries, 10); got < 0.9 {
+		t.Errorf("recall@10 = %v, want at least 0.9", got)
+	}
+
+	// Remove most vectors, which compacts the graph, and replace a few.
+	removed := 0
+	for id := range vectors {
+		if removed == 600 {
+			break
+		}
+		idx.Remove(id)
+		delete(vectors, id)
+		removed++
+	}
+	replaced := randomVectors(50, 16, 3)
+	i := 0
+	for id := range vectors {
+		if i == 50 {
+			break
+		}
+		vectors[id] = replaced[fmt.Sprintf("doc:%d", i)]
+		if err := idx.Add(id, vectors[id]); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+		i++
+	}
+
+	if idx.Len() != len(vectors) {
+		t.Errorf("Len() = %d, want %d", idx.Len(), len(vectors))
+	}
+	if got := recall(t, idx, vectors, queries, 10); got < 0.9 {
+		t.Errorf("recall@10 after removals = %v, want at least 0.9", got)
+	}
+}
+
+func TestIndexSearchExactMatch(t *testing.T) {
+	idx := New(4, 16)
+	for id, vector := range map[string][]float32{
+		"a": {1, 0, 0},
+		"b": {0, 1, 0},
+		"c": {0.9, 0.1, 0},
+	} {
+		if err := idx.Add(id, vector); err != nil {
+			t.Fatalf("Add() error = %v", err)
+		}
+	}
+
+	results, err := idx.Search([]float32{2, 0, 0}, 2, 0)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if len(results) != 2 || results[0].ID != "a" || results[1].ID != "c" {
+		t.Fatalf("Search() = %v, want a then c", results)
+	}
+	if results[0].Similarity < 0.999 {
+		t.Errorf("Search() similarity = %v, want 1", results[0].Similarity)
+	}
+
+	idx.Remove("a")
+	results, _ = idx.Search([]float32{2, 0, 0}, 1, 0)
+	if len(results) != 1 || results[0].ID != "c" {
+		t.Errorf("Search() after Remove = %v, want c", results)
+	}
+}
+
+func TestIndexRejectsInvalidVectors(t *testing.T) {
+	idx := New(0, 0)
+	if err := idx.Add("zero", []float32{0, 0}); err == nil {
+		t.Error("Add() expected an error for a zero vector")
+	}
+	if err := idx.Add("a", []float32{1, 0}); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if err := idx.Add("b", []float32{1, 0, 0}); err == nil {
+		t.Error("Add() expected an error for a dimension mismatch")
+	}
+	if _, err := idx.Search([]float32{1}, 1, 0); err == nil {
+		t.Error("Search() expected an error for a dimension mismatch")
+	}
+}
@@ -45,6 +45,49 @@ func Tokenize(content structs.Content, stopWords ...string) []string {
 	return tokens
 }
 
+// StringField is the field name the string content is indexed under.
+const StringField = "string"
+
+// TokenizeFields tokenizes the string content and every indexed object field
+// separately, keyed by field name. Joined together the tokens match Tokenize.
+func TokenizeFields(content structs.Content, stopWords ...string) map[string][]string {
+	fields := make(map[string][]string)
+
+	if tokens := tokenizeText(strings.ToLower(content.String), stopWords...); len(tokens) > 0 {
+		fields[StringField] = tokens
+	}
+
+	if len(content.Object) > 0 {
+		if len(content.ObjectIndexes) > 0 {
+			for _, index := range content.ObjectIndexes {
+				if v, ok := content.Object[index]; ok {
+					if tokens := tokenizeText(util.InterfaceToString(v), stopWords...); len(tokens) > 0 {
+						fields[index] = tokens
+					}
+				}
+			}
+		} else {
+			for field, v := range content.Object {
+				if tokens := tokenizeText(util.InterfaceToString(v), stopWords...); len(tokens) > 0 {
+					fields[field] = tokens
+				}
+			}
+		}
+	}
+
+	return fields
+}
+
+func tokenizeText(text string, stopWords ...string) []string {
+	filteredBuf := bytes.Buffer{}
+	for _, r := range text {
+		if r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z' || r >= '0' && r <= '9' || r == ' ' {
+			filteredBuf.WriteRune(r)
+		}
+	}
+	return removeStopWords(filteredBuf.String(), stopWords...)
+}
+
 func removeStopWords(text string, stopWords ...string) []string {
 	words := strings.Fields(text)
 	filteredWords := make([]string, 0, len(words))
@@ -63,3 +106,9 @@ func removeStopWords(text string, stopWords ...string) []string {
 
 	return filteredWords
 }
+
+// TokenizeQuery splits query text into terms the way object field values are
+// indexed: punctuation and stop words are dropped and case is kept.
+func TokenizeQuery(text string, stopWords ...string) []string {
+	return tokenizeText(text, stopWords...)
+}
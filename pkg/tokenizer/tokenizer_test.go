@@ -35,3 +35,50 @@ func TestTokenize(t *testing.T) {
 		})
 	}
 }
+
+func TestTokenizeFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   structs.Content
+		stopWords []string
+		want      map[string][]string
+	}{
+		{
+			name: "string and indexed object fields",
+			content: structs.Content{
+				String: "Journal no: 980034 TRANSFER DARI Bpk TEDDY",
+				Object: map[string]interface{}{
+					"sender_name":  "ahmad reza",
+					"total_amount": 71495150,
+					"notes":        "not indexed",
+				},
+				ObjectIndexes: []string{"sender_name", "total_amount"},
+			},
+			want: map[string][]string{
+				"string":       {"980034", "teddy"},
+				"sender_name":  {"ahmad", "reza"},
+				"total_amount": {"71495150"},
+			},
+		},
+		{
+			name: "stop words and empty fields are dropped",
+			content: structs.Content{
+				Object: map[string]interface{}{
+					"remark": " ffb20508 via api",
+					"notes":  "",
+				},
+			},
+			stopWords: []string{"api"},
+			want: map[string][]string{
+				"remark": {"ffb20508"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := TokenizeFields(tt.content, tt.stopWords...); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("TokenizeFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}